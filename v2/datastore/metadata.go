@@ -8,16 +8,25 @@ import "context"
 
 // Datastore kinds for the metadata entities.
 const (
-	namespaceKind = "__namespace__"
-	kindKind      = "__kind__"
-	propertyKind  = "__property__"
+	namespaceKind   = "__namespace__"
+	kindKind        = "__kind__"
+	propertyKind    = "__property__"
+	entityGroupKind = "__entity_group__"
 )
 
 // Namespaces returns all the datastore namespaces.
 func Namespaces(ctx context.Context) ([]string, error) {
-	// TODO(djd): Support range queries.
-	q := NewQuery(namespaceKind).KeysOnly()
-	keys, err := q.GetAll(ctx, nil)
+	return NamespacesInRange(ctx, "", "", 0)
+}
+
+// NamespacesInRange returns the datastore namespaces n such that
+// start <= n < end, in order. An empty start or end leaves that end of the
+// range unbounded; the empty namespace is only returned if start is empty.
+// If limit is positive, at most limit namespaces are returned. Large lists
+// can be paged through by passing the last namespace returned, plus "\x00",
+// as the next start.
+func NamespacesInRange(ctx context.Context, start, end string, limit int) ([]string, error) {
+	keys, err := metadataKeysInRange(ctx, namespaceKind, start, end, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -28,15 +37,36 @@ func Namespaces(ctx context.Context) ([]string, error) {
 
 // Kinds returns the names of all the kinds in the current namespace.
 func Kinds(ctx context.Context) ([]string, error) {
-	// TODO(djd): Support range queries.
-	q := NewQuery(kindKind).KeysOnly()
-	keys, err := q.GetAll(ctx, nil)
+	return KindsInRange(ctx, "", "", 0)
+}
+
+// KindsInRange returns the names of the kinds k in the current namespace
+// such that start <= k < end, in order. The start, end and limit arguments
+// have the same meaning as for NamespacesInRange.
+func KindsInRange(ctx context.Context, start, end string, limit int) ([]string, error) {
+	keys, err := metadataKeysInRange(ctx, kindKind, start, end, limit)
 	if err != nil {
 		return nil, err
 	}
 	return keyNames(keys), nil
 }
 
+// metadataKeysInRange returns the keys of the metadata entities of the given
+// kind whose names are in the range [start, end).
+func metadataKeysInRange(ctx context.Context, kind, start, end string, limit int) ([]*Key, error) {
+	q := NewQuery(kind).KeysOnly()
+	if start != "" {
+		q = q.Filter("__key__ >=", NewKey(ctx, kind, start, 0, nil))
+	}
+	if end != "" {
+		q = q.Filter("__key__ <", NewKey(ctx, kind, end, 0, nil))
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	return q.GetAll(ctx, nil)
+}
+
 // keyNames returns a slice of the provided keys' names (string IDs).
 func keyNames(keys []*Key) []string {
 	n := make([]string, 0, len(keys))
@@ -77,3 +107,25 @@ func KindProperties(ctx context.Context, kind string) (map[string][]string, erro
 	}
 	return propMap, nil
 }
+
+// EntityGroupVersion returns the version of the entity group containing key.
+// The version is a positive number that is guaranteed to increase on every
+// change to the entity group, so comparing it with a previously recorded
+// version is a cheap way to detect whether any entity in the group has
+// changed. It returns ErrNoSuchEntity if the entity group does not exist.
+//
+// The version is not guaranteed to increase by exactly one, and may increase
+// even when there have been no changes.
+func EntityGroupVersion(ctx context.Context, key *Key) (int64, error) {
+	if key == nil || key.root().Incomplete() {
+		return 0, ErrInvalidKey
+	}
+	egKey := NewKey(ctx, entityGroupKind, "", 1, key.root())
+	var eg struct {
+		Version int64 `datastore:"__version__"`
+	}
+	if err := Get(ctx, egKey, &eg); err != nil {
+		return 0, err
+	}
+	return eg.Version, nil
+}
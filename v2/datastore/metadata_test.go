@@ -0,0 +1,109 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package datastore
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/datastore"
+)
+
+func TestNamespacesInRange(t *testing.T) {
+	testCases := []struct {
+		start, end string
+		limit      int
+		wantOps    []pb.Query_Filter_Operator
+		wantLimit  int32
+	}{
+		{"", "", 0, nil, 0},
+		{"a", "", 0, []pb.Query_Filter_Operator{pb.Query_Filter_GREATER_THAN_OR_EQUAL}, 0},
+		{"", "m", 10, []pb.Query_Filter_Operator{pb.Query_Filter_LESS_THAN}, 10},
+		{"a", "m", 0, []pb.Query_Filter_Operator{pb.Query_Filter_GREATER_THAN_OR_EQUAL, pb.Query_Filter_LESS_THAN}, 0},
+	}
+	for _, tc := range testCases {
+		c := aetesting.FakeSingleContext(t, "datastore_v3", "RunQuery", func(in *pb.Query, out *pb.QueryResult) error {
+			if in.GetKind() != namespaceKind {
+				return fmt.Errorf("kind = %q, want %q", in.GetKind(), namespaceKind)
+			}
+			var ops []pb.Query_Filter_Operator
+			for _, f := range in.Filter {
+				ops = append(ops, f.GetOp())
+			}
+			if !reflect.DeepEqual(ops, tc.wantOps) {
+				return fmt.Errorf("filter ops = %v, want %v", ops, tc.wantOps)
+			}
+			if in.GetLimit() != tc.wantLimit {
+				return fmt.Errorf("limit = %d, want %d", in.GetLimit(), tc.wantLimit)
+			}
+			path := &pb.Path{Element: []*pb.Path_Element{{
+				Type: proto.String(namespaceKind),
+				Name: proto.String("b"),
+			}}}
+			*out = pb.QueryResult{
+				Result: []*pb.EntityProto{{
+					Key:         &pb.Reference{App: proto.String("s~test-app"), Path: path},
+					EntityGroup: path,
+				}},
+				MoreResults: proto.Bool(false),
+			}
+			return nil
+		})
+		c = internal.WithAppIDOverride(c, "dev~fake-app")
+
+		got, err := NamespacesInRange(c, tc.start, tc.end, tc.limit)
+		if err != nil {
+			t.Errorf("NamespacesInRange(%q, %q, %d): %v", tc.start, tc.end, tc.limit, err)
+			continue
+		}
+		if want := []string{"b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("NamespacesInRange(%q, %q, %d) = %q, want %q", tc.start, tc.end, tc.limit, got, want)
+		}
+	}
+}
+
+func TestEntityGroupVersion(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "datastore_v3", "Get", func(in *pb.GetRequest, out *pb.GetResponse) error {
+		if len(in.Key) != 1 {
+			return fmt.Errorf("got %d keys, want 1", len(in.Key))
+		}
+		e := in.Key[0].Path.Element
+		if len(e) != 2 || e[0].GetName() != "root" || e[1].GetType() != entityGroupKind || e[1].GetId() != 1 {
+			return fmt.Errorf("unexpected key: %v", in.Key[0])
+		}
+		*out = pb.GetResponse{
+			Entity: []*pb.GetResponse_Entity{{
+				Entity: &pb.EntityProto{
+					Key:         in.Key[0],
+					EntityGroup: &pb.Path{Element: e[:1]},
+					Property: []*pb.Property{{
+						Name:  proto.String("__version__"),
+						Value: &pb.PropertyValue{Int64Value: proto.Int64(7)},
+					}},
+				},
+			}},
+		}
+		return nil
+	})
+	c = internal.WithAppIDOverride(c, "dev~fake-app")
+
+	root := NewKey(c, "Gopher", "root", 0, nil)
+	v, err := EntityGroupVersion(c, NewKey(c, "Gopher", "", 3, root))
+	if err != nil {
+		t.Fatalf("EntityGroupVersion: %v", err)
+	}
+	if v != 7 {
+		t.Errorf("EntityGroupVersion = %d, want 7", v)
+	}
+
+	if _, err := EntityGroupVersion(c, NewIncompleteKey(c, "Gopher", nil)); err != ErrInvalidKey {
+		t.Errorf("EntityGroupVersion(incomplete key): got %v, want %v", err, ErrInvalidKey)
+	}
+}
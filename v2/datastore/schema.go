@@ -0,0 +1,202 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// SchemaProblem identifies the kind of difference reported by a
+// SchemaMismatch.
+type SchemaProblem int
+
+const (
+	// MissingField means that the datastore has an indexed property for
+	// which the struct has no field. Loading such an entity into the struct
+	// returns an *ErrFieldMismatch.
+	MissingField SchemaProblem = iota
+	// TypeChanged means that the datastore has values for the property whose
+	// representation type cannot be loaded into the struct field.
+	TypeChanged
+	// IndexedButNoIndex means that the property is indexed in the datastore
+	// but the struct field is tagged noindex, so newly saved entities will
+	// drop out of the property's index.
+	IndexedButNoIndex
+	// NotIndexed means that the struct field is indexed but the datastore
+	// has no indexed values for it. Either no entity has been saved with the
+	// field yet, or existing entities store it unindexed, in which case
+	// queries on the property will not see them.
+	NotIndexed
+)
+
+var schemaProblemNames = map[SchemaProblem]string{
+	MissingField:      "missing field",
+	TypeChanged:       "type changed",
+	IndexedButNoIndex: "indexed but noindex",
+	NotIndexed:        "not indexed",
+}
+
+func (p SchemaProblem) String() string {
+	if s, ok := schemaProblemNames[p]; ok {
+		return s
+	}
+	return fmt.Sprintf("SchemaProblem(%d)", int(p))
+}
+
+// SchemaMismatch describes a difference between a Go struct and the
+// properties stored in the datastore for a kind.
+// StructType is the type of the struct passed to CheckSchema or
+// CompareSchema.
+type SchemaMismatch struct {
+	StructType reflect.Type
+	FieldName  string
+	Problem    SchemaProblem
+	Reason     string
+}
+
+func (m *SchemaMismatch) Error() string {
+	return fmt.Sprintf("datastore: schema mismatch for field %q in %q: %s",
+		m.FieldName, m.StructType, m.Reason)
+}
+
+// CheckSchema compares the struct pointed to by src against the indexed
+// properties stored for kind in the current namespace, as reported by
+// KindProperties, and returns the differences it finds. It returns a nil
+// slice if the struct and the stored properties agree.
+//
+// The datastore only records metadata for indexed properties, so properties
+// that are always stored unindexed cannot be checked.
+func CheckSchema(ctx context.Context, kind string, src interface{}) ([]*SchemaMismatch, error) {
+	props, err := KindProperties(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	return CompareSchema(src, props)
+}
+
+// CompareSchema is like CheckSchema but compares the struct pointed to by src
+// against props, which is in the format returned by KindProperties. It makes
+// no API calls and so is suitable for use in tests and tools that obtain the
+// properties by other means.
+func CompareSchema(src interface{}, props map[string][]string) ([]*SchemaMismatch, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidEntityType
+	}
+	if v.Type().Implements(typeOfPropertyLoadSaver) {
+		return nil, fmt.Errorf("datastore: cannot compare schema of %v: it implements PropertyLoadSaver", v.Type())
+	}
+	st := v.Type().Elem()
+	codec, err := getStructCodec(st)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]schemaField)
+	collectSchemaFields(fields, st, codec, "", false)
+
+	var ms []*SchemaMismatch
+	add := func(name string, p SchemaProblem, format string, args ...interface{}) {
+		ms = append(ms, &SchemaMismatch{
+			StructType: st,
+			FieldName:  name,
+			Problem:    p,
+			Reason:     fmt.Sprintf(format, args...),
+		})
+	}
+	for name, reprs := range props {
+		f, ok := fields[name]
+		if !ok {
+			add(name, MissingField, "stored property has no struct field")
+			continue
+		}
+		want := representation(f.typ)
+		for _, r := range reprs {
+			if r != want && r != "NULL" {
+				add(name, TypeChanged, "stored as %s but field type %v is %s", r, f.typ, want)
+			}
+		}
+		if f.noIndex {
+			add(name, IndexedButNoIndex, "stored property is indexed but field is noindex")
+		}
+	}
+	for name, f := range fields {
+		if _, ok := props[name]; !ok && !f.noIndex {
+			add(name, NotIndexed, "field is indexed but the datastore has no indexed values")
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].FieldName != ms[j].FieldName {
+			return ms[i].FieldName < ms[j].FieldName
+		}
+		if ms[i].Problem != ms[j].Problem {
+			return ms[i].Problem < ms[j].Problem
+		}
+		return ms[i].Reason < ms[j].Reason
+	})
+	return ms, nil
+}
+
+// schemaField is a flattened property of a struct, as it would be saved.
+type schemaField struct {
+	// typ is the field's type, or its element type for slice fields.
+	typ     reflect.Type
+	noIndex bool
+}
+
+// collectSchemaFields adds the properties that a struct of type t with codec c
+// saves to dst, following the same flattening rules as structPLS.save.
+func collectSchemaFields(dst map[string]schemaField, t reflect.Type, c *structCodec, prefix string, noIndex bool) {
+	for name, f := range c.fields {
+		if name == "__key__" {
+			continue
+		}
+		name = prefix + name
+		ft := t.FieldByIndex(f.path).Type
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+			ft = ft.Elem()
+		}
+		fNoIndex := noIndex || f.noIndex
+		if f.structCodec != nil {
+			collectSchemaFields(dst, ft, f.structCodec, name+".", fNoIndex)
+			continue
+		}
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Uint8 && ft != typeOfByteString {
+			// []byte values are always saved unindexed.
+			fNoIndex = true
+		}
+		dst[name] = schemaField{typ: ft, noIndex: fNoIndex}
+	}
+}
+
+// representation returns the KindProperties representation type for values
+// of type t, or "" if t is not a supported property type.
+func representation(t reflect.Type) string {
+	switch t {
+	case typeOfTime:
+		return "INT64"
+	case typeOfGeoPoint:
+		return "POINT"
+	case typeOfKeyPtr:
+		return "REFERENCE"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "INT64"
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.String:
+		return "STRING"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "STRING"
+		}
+	}
+	return ""
+}
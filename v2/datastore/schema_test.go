@@ -0,0 +1,83 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package datastore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompareSchema(t *testing.T) {
+	type inner struct {
+		W float64
+	}
+	type schemaStruct struct {
+		A int64
+		B string `datastore:",noindex"`
+		C time.Time
+		D []byte
+		E []*Key
+		F inner
+		K *Key `datastore:"__key__"`
+	}
+
+	type want struct {
+		name    string
+		problem SchemaProblem
+	}
+	testCases := []struct {
+		desc  string
+		props map[string][]string
+		want  []want
+	}{
+		{
+			desc: "match",
+			props: map[string][]string{
+				"A":   {"INT64"},
+				"C":   {"INT64", "NULL"},
+				"E":   {"REFERENCE"},
+				"F.W": {"DOUBLE"},
+			},
+		},
+		{
+			desc: "drift",
+			props: map[string][]string{
+				"A":   {"STRING"},
+				"B":   {"STRING"},
+				"E":   {"REFERENCE"},
+				"F.W": {"DOUBLE"},
+				"Old": {"BOOLEAN"},
+			},
+			want: []want{
+				{"A", TypeChanged},
+				{"B", IndexedButNoIndex},
+				{"C", NotIndexed},
+				{"Old", MissingField},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		ms, err := CompareSchema(&schemaStruct{}, tc.props)
+		if err != nil {
+			t.Errorf("%s: CompareSchema: %v", tc.desc, err)
+			continue
+		}
+		var got []want
+		for _, m := range ms {
+			if m.StructType != reflect.TypeOf(schemaStruct{}) {
+				t.Errorf("%s: StructType = %v", tc.desc, m.StructType)
+			}
+			got = append(got, want{m.FieldName, m.Problem})
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+
+	if _, err := CompareSchema(schemaStruct{}, nil); err != ErrInvalidEntityType {
+		t.Errorf("CompareSchema(non-pointer): got %v, want %v", err, ErrInvalidEntityType)
+	}
+}
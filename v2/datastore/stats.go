@@ -0,0 +1,184 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/appengine/v2/internal"
+)
+
+// Datastore kinds for the statistics entities. The global statistics are
+// stored in the empty namespace and cover the whole application; the
+// "Ns" variants are stored in each namespace and cover only that namespace.
+const (
+	statTotalKind          = "__Stat_Total__"
+	statKindKind           = "__Stat_Kind__"
+	statPropertyTypeKind   = "__Stat_PropertyType__"
+	statNamespaceKind      = "__Stat_Namespace__"
+	statNsTotalKind        = "__Stat_Ns_Total__"
+	statNsKindKind         = "__Stat_Ns_Kind__"
+	statNsPropertyTypeKind = "__Stat_Ns_PropertyType__"
+)
+
+// Stats holds the values common to all the datastore statistics entities.
+// The statistics are computed periodically by the datastore, so they may be
+// up to a day or two old; Timestamp records when they were computed.
+//
+// The datastore does not record composite index sizes for every statistic,
+// in which case CompositeIndexBytes and CompositeIndexCount are zero.
+type Stats struct {
+	// Count is the number of entities.
+	Count int64 `datastore:"count"`
+	// Bytes is the total storage used, including entities and indexes.
+	Bytes int64 `datastore:"bytes"`
+	// EntityBytes is the storage used by the entities themselves.
+	EntityBytes int64 `datastore:"entity_bytes"`
+	// BuiltinIndexBytes and BuiltinIndexCount are the storage used by, and
+	// the number of, built-in index entries.
+	BuiltinIndexBytes int64 `datastore:"builtin_index_bytes"`
+	BuiltinIndexCount int64 `datastore:"builtin_index_count"`
+	// CompositeIndexBytes and CompositeIndexCount are the storage used by,
+	// and the number of, composite index entries.
+	CompositeIndexBytes int64 `datastore:"composite_index_bytes"`
+	CompositeIndexCount int64 `datastore:"composite_index_count"`
+	// Timestamp is when the statistics were computed.
+	Timestamp time.Time `datastore:"timestamp"`
+}
+
+// KindStat holds the statistics for a single kind.
+type KindStat struct {
+	KindName string `datastore:"kind_name"`
+	Stats
+}
+
+// PropertyTypeStat holds the statistics for a single property
+// representation type, such as "String" or "Integer".
+type PropertyTypeStat struct {
+	PropertyType string `datastore:"property_type"`
+	Stats
+}
+
+// NamespaceStat holds the statistics for a single namespace.
+type NamespaceStat struct {
+	SubjectNamespace string `datastore:"subject_namespace"`
+	Stats
+}
+
+// TotalStats returns the statistics for all the entities in the application,
+// across all namespaces.
+func TotalStats(ctx context.Context) (*Stats, error) {
+	var s []*Stats
+	if err := getStats(globalStatsContext(ctx), NewQuery(statTotalKind), &s); err != nil {
+		return nil, err
+	}
+	if len(s) == 0 {
+		return nil, ErrNoSuchEntity
+	}
+	return s[0], nil
+}
+
+// KindStats returns the statistics for the named kind, across all
+// namespaces. It returns ErrNoSuchEntity if the datastore has not yet
+// computed statistics for the kind.
+func KindStats(ctx context.Context, kind string) (*KindStat, error) {
+	return kindStats(globalStatsContext(ctx), statKindKind, kind)
+}
+
+// AllKindStats returns the statistics for every kind, across all namespaces.
+func AllKindStats(ctx context.Context) ([]*KindStat, error) {
+	var s []*KindStat
+	if err := getStats(globalStatsContext(ctx), NewQuery(statKindKind), &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// PropertyTypeStats returns the statistics for every property representation
+// type, across all namespaces.
+func PropertyTypeStats(ctx context.Context) ([]*PropertyTypeStat, error) {
+	var s []*PropertyTypeStat
+	if err := getStats(globalStatsContext(ctx), NewQuery(statPropertyTypeKind), &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NamespaceStats returns the statistics for every namespace.
+func NamespaceStats(ctx context.Context) ([]*NamespaceStat, error) {
+	var s []*NamespaceStat
+	if err := getStats(globalStatsContext(ctx), NewQuery(statNamespaceKind), &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NsTotalStats is like TotalStats but only covers the current namespace.
+func NsTotalStats(ctx context.Context) (*Stats, error) {
+	var s []*Stats
+	if err := getStats(ctx, NewQuery(statNsTotalKind), &s); err != nil {
+		return nil, err
+	}
+	if len(s) == 0 {
+		return nil, ErrNoSuchEntity
+	}
+	return s[0], nil
+}
+
+// NsKindStats is like KindStats but only covers the current namespace.
+func NsKindStats(ctx context.Context, kind string) (*KindStat, error) {
+	return kindStats(ctx, statNsKindKind, kind)
+}
+
+// NsAllKindStats is like AllKindStats but only covers the current namespace.
+func NsAllKindStats(ctx context.Context) ([]*KindStat, error) {
+	var s []*KindStat
+	if err := getStats(ctx, NewQuery(statNsKindKind), &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NsPropertyTypeStats is like PropertyTypeStats but only covers the current
+// namespace.
+func NsPropertyTypeStats(ctx context.Context) ([]*PropertyTypeStat, error) {
+	var s []*PropertyTypeStat
+	if err := getStats(ctx, NewQuery(statNsPropertyTypeKind), &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// kindStats returns the single statistics entity of the given stat kind for
+// the named kind.
+func kindStats(ctx context.Context, statKind, kind string) (*KindStat, error) {
+	var s []*KindStat
+	q := NewQuery(statKind).Filter("kind_name =", kind).Limit(1)
+	if err := getStats(ctx, q, &s); err != nil {
+		return nil, err
+	}
+	if len(s) == 0 {
+		return nil, ErrNoSuchEntity
+	}
+	return s[0], nil
+}
+
+// globalStatsContext returns a context for the empty namespace, where the
+// global statistics entities are stored.
+func globalStatsContext(ctx context.Context) context.Context {
+	return internal.NamespacedContext(ctx, "")
+}
+
+// getStats runs q, loading the results into dst. The statistics entities
+// have more properties than the typed structs, and the set varies between
+// kinds, so field mismatches are ignored.
+func getStats(ctx context.Context, q *Query, dst interface{}) error {
+	_, err := q.GetAll(ctx, dst)
+	if _, ok := err.(*ErrFieldMismatch); ok {
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package datastore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/datastore"
+)
+
+func TestKindStats(t *testing.T) {
+	statPath := &pb.Path{
+		Element: []*pb.Path_Element{{
+			Type: proto.String(statKindKind),
+			Name: proto.String("Gopher"),
+		}},
+	}
+	c := aetesting.FakeSingleContext(t, "datastore_v3", "RunQuery", func(in *pb.Query, out *pb.QueryResult) error {
+		if in.GetKind() != statKindKind {
+			return fmt.Errorf("kind = %q, want %q", in.GetKind(), statKindKind)
+		}
+		if in.NameSpace != nil {
+			return fmt.Errorf("namespace = %q, want none", in.GetNameSpace())
+		}
+		if len(in.Filter) != 1 || in.Filter[0].Property[0].GetName() != "kind_name" {
+			return fmt.Errorf("unexpected filter: %v", in.Filter)
+		}
+		*out = pb.QueryResult{
+			Result: []*pb.EntityProto{{
+				Key: &pb.Reference{
+					App:  proto.String("s~test-app"),
+					Path: statPath,
+				},
+				EntityGroup: statPath,
+				Property: []*pb.Property{
+					{Name: proto.String("kind_name"), Value: &pb.PropertyValue{StringValue: proto.String("Gopher")}},
+					{Name: proto.String("count"), Value: &pb.PropertyValue{Int64Value: proto.Int64(42)}},
+					{Name: proto.String("bytes"), Value: &pb.PropertyValue{Int64Value: proto.Int64(4096)}},
+					// An unknown property must not cause an error.
+					{Name: proto.String("new_stat"), Value: &pb.PropertyValue{Int64Value: proto.Int64(1)}},
+				},
+			}},
+			MoreResults: proto.Bool(false),
+		}
+		return nil
+	})
+	c = internal.WithAppIDOverride(c, "dev~fake-app")
+	c = internal.NamespacedContext(c, "other")
+
+	s, err := KindStats(c, "Gopher")
+	if err != nil {
+		t.Fatalf("KindStats: %v", err)
+	}
+	if s.KindName != "Gopher" || s.Count != 42 || s.Bytes != 4096 {
+		t.Errorf("KindStats = %+v, want Gopher with 42 entities and 4096 bytes", s)
+	}
+}
@@ -7,6 +7,8 @@ package datastore
 import (
 	"context"
 	"errors"
+	"math/rand"
+	"time"
 
 	"google.golang.org/appengine/v2/internal"
 	pb "google.golang.org/appengine/v2/internal/datastore"
@@ -51,8 +53,19 @@ var ErrConcurrentTransaction = errors.New("datastore: concurrent transaction")
 // Since f may be called multiple times, f should usually be idempotent.
 // datastore.Get is not idempotent when unmarshaling slice fields.
 //
-// Nested transactions are not supported; c may not be a transaction context.
+// By default f is retried immediately after a conflict. Setting
+// TransactionOptions.Backoff spaces out the attempts, which reduces
+// contention on busy entity groups. Within f, TransactionAttempt reports
+// which attempt is running, so that side effects that are not undone by a
+// rollback can be reasoned about.
+//
+// Nested transactions are not supported; c may not be a transaction context
+// unless TransactionOptions.Join is set, in which case f joins the
+// transaction of c.
 func RunInTransaction(c context.Context, f func(tc context.Context) error, opts *TransactionOptions) error {
+	if opts != nil && opts.Join && InTransaction(c) {
+		return f(c)
+	}
 	xg := false
 	if opts != nil {
 		xg = opts.XG
@@ -68,13 +81,92 @@ func RunInTransaction(c context.Context, f func(tc context.Context) error, opts
 	var t *pb.Transaction
 	var err error
 	for i := 0; i < attempts; i++ {
-		if t, err = internal.RunTransactionOnce(c, f, xg, readOnly, t); err != internal.ErrConcurrentTransaction {
+		if i > 0 {
+			if opts != nil && opts.OnRetry != nil {
+				opts.OnRetry(i, ErrConcurrentTransaction)
+			}
+			if opts != nil && opts.Backoff != nil {
+				if err := sleepContext(c, opts.Backoff.delay(i)); err != nil {
+					return err
+				}
+			}
+		}
+		attempt := i + 1
+		g := func(tc context.Context) error {
+			return f(context.WithValue(tc, &transactionAttemptKey, attempt))
+		}
+		if t, err = internal.RunTransactionOnce(c, g, xg, readOnly, t); err != internal.ErrConcurrentTransaction {
 			return err
 		}
 	}
 	return ErrConcurrentTransaction
 }
 
+// InTransaction reports whether c is a transaction context, such as the
+// context passed to the function given to RunInTransaction.
+func InTransaction(c context.Context) bool {
+	return internal.InTransaction(c)
+}
+
+var transactionAttemptKey = "holds the transaction attempt number"
+
+// TransactionAttempt returns the number of the RunInTransaction attempt that
+// is running with the transaction context c, starting from 1. A result
+// greater than 1 means that f is being retried after a conflict, and that
+// any side effects of the earlier attempts outside the datastore, other than
+// transactional tasks, have already happened. It returns 0 if c is not a
+// transaction context created by RunInTransaction.
+func TransactionAttempt(c context.Context) int {
+	n, _ := c.Value(&transactionAttemptKey).(int)
+	return n
+}
+
+// Backoff describes an exponential backoff between transaction attempts.
+// The delay before the n'th retry is Initial * Multiplier^(n-1), capped at
+// Max, and then reduced by a random amount of up to half to avoid retrying
+// in lockstep with the conflicting transaction.
+type Backoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max is the maximum delay. If zero, the delay is not capped.
+	Max time.Duration
+	// Multiplier is the factor by which the delay grows with each retry.
+	// If less than 1, it defaults to 2.
+	Multiplier float64
+}
+
+// delay returns the jittered delay before the given retry, starting from 1.
+func (b *Backoff) delay(retry int) time.Duration {
+	m := b.Multiplier
+	if m < 1 {
+		m = 2
+	}
+	d := float64(b.Initial)
+	for i := 1; i < retry && (b.Max <= 0 || d < float64(b.Max)); i++ {
+		d *= m
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	return time.Duration(d/2 + rand.Float64()*d/2)
+}
+
+// sleepContext waits for d, returning early with the context's error if c is
+// done first.
+func sleepContext(c context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
 // TransactionOptions are the options for running a transaction.
 type TransactionOptions struct {
 	// XG is whether the transaction can cross multiple entity groups. In
@@ -92,4 +184,20 @@ type TransactionOptions struct {
 	// ReadOnly controls whether the transaction is a read only transaction.
 	// Read only transactions are potentially more efficient.
 	ReadOnly bool
+	// Backoff, if non-nil, controls the delay between attempts. If nil,
+	// a failed attempt is retried immediately.
+	Backoff *Backoff
+	// OnRetry, if non-nil, is called before each retry with the number of
+	// the retry, starting from 1, and the error that caused it.
+	OnRetry func(retry int, err error)
+	// Join controls what happens when RunInTransaction is called with a
+	// transaction context. If false, it returns an error because nested
+	// transactions are not supported. If true, f is called once with that
+	// context, so that its operations become part of the outer transaction
+	// and are committed or rolled back with it; f's error is returned
+	// unchanged and the other options are ignored. This lets transactional
+	// helpers be called both on their own and from inside a caller's
+	// transaction. The outer transaction must be compatible with f, for
+	// example by being cross-group if f touches several entity groups.
+	Join bool
 }
@@ -0,0 +1,130 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/datastore"
+)
+
+// fakeTransactionContext returns a context whose first conflicts commits fail
+// with a concurrent transaction error.
+func fakeTransactionContext(conflicts int) context.Context {
+	commits := 0
+	c := internal.WithCallOverride(internal.ContextForTesting(&http.Request{}), func(ctx context.Context, service, method string, in, out proto.Message) error {
+		switch method {
+		case "BeginTransaction":
+			out.(*pb.Transaction).Handle = proto.Uint64(1)
+			return nil
+		case "Rollback":
+			return nil
+		case "Commit":
+			commits++
+			if commits <= conflicts {
+				return &internal.APIError{Service: service, Code: int32(pb.Error_CONCURRENT_TRANSACTION)}
+			}
+			return nil
+		}
+		return fmt.Errorf("unexpected call to /%s.%s", service, method)
+	})
+	return internal.WithAppIDOverride(c, "dev~fake-app")
+}
+
+func TestRunInTransactionRetries(t *testing.T) {
+	c := fakeTransactionContext(2)
+
+	if n := TransactionAttempt(c); n != 0 {
+		t.Errorf("TransactionAttempt outside a transaction = %d, want 0", n)
+	}
+	var attempts, retries []int
+	opts := &TransactionOptions{
+		Backoff: &Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond},
+		OnRetry: func(retry int, err error) {
+			if err != ErrConcurrentTransaction {
+				t.Errorf("OnRetry err = %v, want %v", err, ErrConcurrentTransaction)
+			}
+			retries = append(retries, retry)
+		},
+	}
+	err := RunInTransaction(c, func(tc context.Context) error {
+		attempts = append(attempts, TransactionAttempt(tc))
+		return nil
+	}, opts)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if got, want := fmt.Sprint(attempts), "[1 2 3]"; got != want {
+		t.Errorf("attempts = %s, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(retries), "[1 2]"; got != want {
+		t.Errorf("retries = %s, want %s", got, want)
+	}
+
+	c = fakeTransactionContext(3)
+	err = RunInTransaction(c, func(tc context.Context) error { return nil }, nil)
+	if err != ErrConcurrentTransaction {
+		t.Errorf("RunInTransaction with 3 conflicts: got %v, want %v", err, ErrConcurrentTransaction)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := &Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	testCases := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tc := range testCases {
+		for i := 0; i < 10; i++ {
+			if d := b.delay(tc.retry); d < tc.max/2 || d > tc.max {
+				t.Errorf("delay(%d) = %v, want between %v and %v", tc.retry, d, tc.max/2, tc.max)
+			}
+		}
+	}
+}
+
+func TestRunInTransactionJoin(t *testing.T) {
+	c := fakeTransactionContext(0)
+
+	if InTransaction(c) {
+		t.Error("InTransaction outside a transaction = true, want false")
+	}
+	calls := 0
+	err := RunInTransaction(c, func(tc context.Context) error {
+		if !InTransaction(tc) {
+			t.Error("InTransaction inside a transaction = false, want true")
+		}
+		if err := RunInTransaction(tc, func(context.Context) error { return nil }, nil); err == nil {
+			t.Error("nested RunInTransaction without Join: got nil error")
+		}
+		return RunInTransaction(tc, func(jc context.Context) error {
+			calls++
+			if jc != tc {
+				t.Error("joined transaction got a different context")
+			}
+			return nil
+		}, &TransactionOptions{Join: true})
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("joined function called %d times, want 1", calls)
+	}
+}
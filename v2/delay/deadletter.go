@@ -0,0 +1,138 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/appengine/v2/datastore"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+// Permanent wraps err to report that a delayed function failed in a way that
+// retrying cannot fix. When a delayed function returns such an error, its
+// task is acknowledged instead of being retried, and it is handed to the
+// function's dead letter policy, if any. Permanent(nil) returns nil.
+//
+// Permanent is the same as taskqueue.NoRetry, so delayed functions and
+// handlers created by taskqueue.Handler may use either.
+func Permanent(err error) error {
+	return taskqueue.NoRetry(err)
+}
+
+// IsPermanent reports whether err, or an error it wraps, was returned by
+// Permanent or taskqueue.NoRetry.
+func IsPermanent(err error) bool {
+	return taskqueue.IsNoRetry(err)
+}
+
+// DeadLetter describes an invocation of a delayed function that failed for
+// the last time.
+type DeadLetter struct {
+	// Key is the key under which the function was registered.
+	Key string
+	// Args are the arguments of the invocation, without the context.
+	Args []interface{}
+	// Payload is the payload of the task. A task with this payload and the
+	// function's path runs the invocation again.
+	Payload []byte
+	// Err is the error returned by the last execution.
+	Err error
+	// Permanent reports whether Err was returned by Permanent, rather than
+	// the invocation having failed MaxExecutions times.
+	Permanent bool
+	// Headers are the request headers of the last execution.
+	Headers *taskqueue.RequestHeaders
+}
+
+// DeadLetterPolicy decides when to give up on an invocation of a delayed
+// function and what to do with it.
+type DeadLetterPolicy struct {
+	// MaxExecutions is the number of failed executions, as counted by the
+	// task's TaskExecutionCount header, after which the invocation is
+	// given up on and its task acknowledged. If zero, only invocations that
+	// fail with a Permanent error are given up on, and others are retried
+	// as configured by the queue.
+	MaxExecutions int
+
+	// Handle, if not nil, is called with each invocation given up on. If it
+	// returns an error, the task fails and is retried, so that Handle is
+	// called again.
+	Handle func(c context.Context, d *DeadLetter) error
+}
+
+// defaultDeadLetter is the dead letter policy of functions without one.
+var defaultDeadLetter *DeadLetterPolicy
+
+// SetDeadLetterPolicy sets the dead letter policy of delayed functions that
+// have none of their own. It should be called during initialization.
+func SetDeadLetterPolicy(p *DeadLetterPolicy) {
+	defaultDeadLetter = p
+}
+
+// WithDeadLetterPolicy sets the dead letter policy of f and returns f. Like
+// MustRegister, it must be called in a global scope.
+func (f *Function) WithDeadLetterPolicy(p *DeadLetterPolicy) *Function {
+	f.deadLetter = p
+	return f
+}
+
+func (f *Function) deadLetterPolicy() *DeadLetterPolicy {
+	if f.deadLetter != nil {
+		return f.deadLetter
+	}
+	return defaultDeadLetter
+}
+
+// giveUp reports whether an invocation that failed with err in an execution
+// with the given headers should not be retried.
+func (p *DeadLetterPolicy) giveUp(err error, h *taskqueue.RequestHeaders) bool {
+	if IsPermanent(err) {
+		return true
+	}
+	// TaskExecutionCount counts the previous failed executions.
+	return p != nil && p.MaxExecutions > 0 && h.TaskExecutionCount+1 >= int64(p.MaxExecutions)
+}
+
+// deadLetterEntity is the entity saved by DatastoreDeadLetter.
+type deadLetterEntity struct {
+	Key            string
+	Args           string `datastore:",noindex"`
+	Payload        []byte `datastore:",noindex"`
+	Error          string `datastore:",noindex"`
+	Permanent      bool
+	QueueName      string
+	TaskName       string
+	ExecutionCount int64
+	Time           time.Time
+}
+
+// DatastoreDeadLetter returns a dead letter handler that saves each dead
+// letter as an entity of the given kind, recording the function's key, its
+// arguments, the task payload and the last error. The entity is keyed by the
+// queue and task names, so saving a dead letter again is harmless.
+func DatastoreDeadLetter(kind string) func(c context.Context, d *DeadLetter) error {
+	return func(c context.Context, d *DeadLetter) error {
+		e := &deadLetterEntity{
+			Key:            d.Key,
+			Args:           fmt.Sprintf("%#v", d.Args),
+			Payload:        d.Payload,
+			Error:          d.Err.Error(),
+			Permanent:      d.Permanent,
+			QueueName:      d.Headers.QueueName,
+			TaskName:       d.Headers.TaskName,
+			ExecutionCount: d.Headers.TaskExecutionCount + 1,
+			Time:           time.Now(),
+		}
+		key := datastore.NewIncompleteKey(c, kind, nil)
+		if d.Headers.TaskName != "" {
+			key = datastore.NewKey(c, kind, d.Headers.QueueName+"/"+d.Headers.TaskName, 0, nil)
+		}
+		_, err := datastore.Put(c, key, e)
+		return err
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/datastore"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+var (
+	errPoison     = errors.New("poison")
+	deadLetterErr error
+	deadLetterF   = func(c context.Context, n int) error {
+		return deadLetterErr
+	}
+	deadLetterRegister = MustRegister("deadLetterRegister", deadLetterF)
+)
+
+// runTaskExecution simulates the Task Queue service running a task for the
+// given time, counting from zero.
+func runTaskExecution(c context.Context, payload []byte, execution int) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(payload))
+	req.Header.Set("X-AppEngine-QueueName", "default")
+	req.Header.Set("X-AppEngine-TaskName", "task1")
+	req.Header.Set("X-AppEngine-TaskExecutionCount", strconv.Itoa(execution))
+	rw := httptest.NewRecorder()
+	runFunc(c, rw, req)
+	return rw
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) != nil")
+	}
+	err := Permanent(errPoison)
+	if !IsPermanent(err) || IsPermanent(errPoison) {
+		t.Errorf("IsPermanent(%v) = %v, IsPermanent(%v) = %v; want true, false", err, IsPermanent(err), errPoison, IsPermanent(errPoison))
+	}
+	if err.Error() != errPoison.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), errPoison.Error())
+	}
+	if wrapped := fmt.Errorf("wrapped: %w", err); !IsPermanent(wrapped) {
+		t.Errorf("IsPermanent(%v) = false, want true", wrapped)
+	}
+	if !IsPermanent(taskqueue.NoRetry(errPoison)) || !taskqueue.IsNoRetry(err) {
+		t.Error("Permanent and taskqueue.NoRetry errors are not interchangeable")
+	}
+}
+
+func TestDeadLetterPolicy(t *testing.T) {
+	c := newFakeContext()
+	var letters []*DeadLetter
+	var handleErr error
+	deadLetterRegister.WithDeadLetterPolicy(&DeadLetterPolicy{
+		MaxExecutions: 3,
+		Handle: func(c context.Context, d *DeadLetter) error {
+			letters = append(letters, d)
+			return handleErr
+		},
+	})
+	defer deadLetterRegister.WithDeadLetterPolicy(nil)
+
+	task, err := deadLetterRegister.Task(42)
+	if err != nil {
+		t.Fatalf("Task: %v", err)
+	}
+
+	tests := []struct {
+		desc      string
+		err       error
+		execution int
+		handleErr error
+		wantCode  int
+		wantDead  bool
+	}{
+		{"success", nil, 0, nil, http.StatusOK, false},
+		{"first failure", errPoison, 0, nil, http.StatusInternalServerError, false},
+		{"second failure", errPoison, 1, nil, http.StatusInternalServerError, false},
+		{"last failure", errPoison, 2, nil, http.StatusOK, true},
+		{"permanent failure", Permanent(errPoison), 0, nil, http.StatusOK, true},
+		{"handler failure", Permanent(errPoison), 0, errors.New("datastore down"), http.StatusInternalServerError, true},
+	}
+	for _, tc := range tests {
+		letters, deadLetterErr, handleErr = nil, tc.err, tc.handleErr
+		rw := runTaskExecution(c.ctx, task.Payload, tc.execution)
+		if rw.Code != tc.wantCode {
+			t.Errorf("%s: status %d, want %d", tc.desc, rw.Code, tc.wantCode)
+		}
+		if got := len(letters) == 1; got != tc.wantDead {
+			t.Errorf("%s: got %d dead letters, want dead letter %v", tc.desc, len(letters), tc.wantDead)
+			continue
+		}
+		if !tc.wantDead {
+			continue
+		}
+		d := letters[0]
+		if d.Key != "deadLetterRegister" || !reflect.DeepEqual(d.Args, []interface{}{42}) || !bytes.Equal(d.Payload, task.Payload) {
+			t.Errorf("%s: dead letter %+v does not describe the invocation", tc.desc, d)
+		}
+		if d.Err != tc.err || d.Permanent != IsPermanent(tc.err) || d.Headers.TaskName != "task1" {
+			t.Errorf("%s: dead letter %+v does not describe the failure", tc.desc, d)
+		}
+	}
+}
+
+func TestDefaultDeadLetterPolicy(t *testing.T) {
+	c := newFakeContext()
+	var letters int
+	SetDeadLetterPolicy(&DeadLetterPolicy{
+		Handle: func(c context.Context, d *DeadLetter) error {
+			letters++
+			return nil
+		},
+	})
+	defer SetDeadLetterPolicy(nil)
+
+	task, err := deadLetterRegister.Task(1)
+	if err != nil {
+		t.Fatalf("Task: %v", err)
+	}
+	// Without MaxExecutions, only permanent failures are given up on.
+	deadLetterErr = errPoison
+	if rw := runTaskExecution(c.ctx, task.Payload, 100); rw.Code != http.StatusInternalServerError || letters != 0 {
+		t.Errorf("failure: status %d with %d dead letters, want %d with none", rw.Code, letters, http.StatusInternalServerError)
+	}
+	deadLetterErr = Permanent(errPoison)
+	if rw := runTaskExecution(c.ctx, task.Payload, 0); rw.Code != http.StatusOK || letters != 1 {
+		t.Errorf("permanent failure: status %d with %d dead letters, want %d with one", rw.Code, letters, http.StatusOK)
+	}
+}
+
+func TestDatastoreDeadLetter(t *testing.T) {
+	var put *pb.PutRequest
+	c := aetesting.FakeSingleContext(t, "datastore_v3", "Put", func(req *pb.PutRequest, res *pb.PutResponse) error {
+		put = req
+		res.Key = []*pb.Reference{req.Entity[0].Key}
+		return nil
+	})
+	c = internal.WithAppIDOverride(c, "dev~fake-app")
+	d := &DeadLetter{
+		Key:     "f",
+		Args:    []interface{}{1},
+		Payload: []byte("payload"),
+		Err:     Permanent(errPoison),
+		Headers: &taskqueue.RequestHeaders{QueueName: "q", TaskName: "t", TaskExecutionCount: 4},
+	}
+	if err := DatastoreDeadLetter("DeadLetter")(c, d); err != nil {
+		t.Fatalf("DatastoreDeadLetter: %v", err)
+	}
+	e := put.Entity[0]
+	path := e.Key.Path.Element
+	if len(path) != 1 || path[0].GetType() != "DeadLetter" || path[0].GetName() != "q/t" {
+		t.Errorf("key path = %v, want DeadLetter q/t", path)
+	}
+	props := make(map[string]*pb.PropertyValue)
+	for _, p := range append(e.Property, e.RawProperty...) {
+		props[p.GetName()] = p.Value
+	}
+	if got := props["Error"].GetStringValue(); got != "poison" {
+		t.Errorf("Error property = %q, want %q", got, "poison")
+	}
+	if got := props["ExecutionCount"].GetInt64Value(); got != 5 {
+		t.Errorf("ExecutionCount property = %d, want 5", got)
+	}
+	if !proto.Equal(props["Payload"], &pb.PropertyValue{StringValue: proto.String("payload")}) {
+		t.Errorf("Payload property = %v, want %q", props["Payload"], "payload")
+	}
+}
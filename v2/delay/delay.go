@@ -27,17 +27,26 @@ run in a new request. The delay package uses the Task Queue API to create
 tasks that call the reserved application path "/_ah/queue/go/delay".
 This path may only be marked as "login: admin" or have no access
 restriction; it will fail if marked as "login: required".
+Applications with their own router may serve NewHandler at another path
+set with SetPath.
+
+With Go 1.18 or later, Register1, Register2 and Register3 declare functions
+whose arguments are type checked at compile time instead of when the task
+is created:
+    ```
+    var laterFunc = delay.Register2("key", func(ctx context.Context, a, b string) error {...})
+    ```
 */
 
 package delay // import "google.golang.org/appengine/v2/delay"
 
 import (
-	"bytes"
 	"context"
 	"encoding/gob"
 	"errors"
 	"fmt"
 	"go/build"
+	"io/ioutil"
 	stdlog "log"
 	"net/http"
 	"path/filepath"
@@ -46,7 +55,6 @@ import (
 	"runtime"
 	"strings"
 
-	"google.golang.org/appengine/v2"
 	"google.golang.org/appengine/v2/internal"
 	"google.golang.org/appengine/v2/log"
 	"google.golang.org/appengine/v2/taskqueue"
@@ -56,11 +64,14 @@ import (
 type Function struct {
 	fv  reflect.Value // Kind() == reflect.Func
 	key string
+	enc Encoding
 	err error // any error during initialization
+
+	deadLetter *DeadLetterPolicy
 }
 
 const (
-	// The HTTP path for invocations.
+	// The default HTTP path for invocations.
 	path = "/_ah/queue/go/delay"
 	// Use the default queue.
 	queue = ""
@@ -224,12 +235,17 @@ type invocation struct {
 //
 //	t, _ := f.Task(...)
 //	_, err := taskqueue.Add(c, t, "")
+//
+// The arguments may be followed by CallOptions that configure the task:
+//
+//	err := f.Call(c, a, b, delay.WithQueue("background"), delay.WithDelay(time.Minute))
 func (f *Function) Call(c context.Context, args ...interface{}) error {
+	args, opts := splitOptions(args)
 	t, err := f.Task(args...)
 	if err != nil {
 		return err
 	}
-	_, err = taskqueueAdder(c, t, queue)
+	_, err = taskqueueAdder(c, t, applyOptions(t, opts))
 	return err
 }
 
@@ -288,19 +304,13 @@ func (f *Function) Task(args ...interface{}) (*taskqueue.Task, error) {
 		}
 	}
 
-	inv := invocation{
-		Key:  f.key,
-		Args: args,
-	}
-
-	buf := new(bytes.Buffer)
-	if err := gob.NewEncoder(buf).Encode(inv); err != nil {
-		return nil, fmt.Errorf("delay: gob encoding failed: %v", err)
+	payload, err := f.encode(args)
+	if err != nil {
+		return nil, err
 	}
-
 	return &taskqueue.Task{
-		Path:    path,
-		Payload: buf.Bytes(),
+		Path:    taskPath,
+		Payload: payload,
 	}, nil
 }
 
@@ -316,57 +326,79 @@ func RequestHeaders(c context.Context) (*taskqueue.RequestHeaders, error) {
 var taskqueueAdder = taskqueue.Add // for testing
 
 func init() {
-	http.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
-		runFunc(appengine.NewContext(req), w, req)
-	})
+	http.Handle(path, NewHandler())
 }
 
 func runFunc(c context.Context, w http.ResponseWriter, req *http.Request) {
 	defer req.Body.Close()
 
-	c = context.WithValue(c, headersContextKey, taskqueue.ParseRequestHeaders(req.Header))
+	headers := taskqueue.ParseRequestHeaders(req.Header)
+	c = context.WithValue(c, headersContextKey, headers)
 
-	var inv invocation
-	if err := gob.NewDecoder(req.Body).Decode(&inv); err != nil {
+	payload, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		log.Errorf(c, "delay: failed reading task payload (will retry): %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	key, decodeArgs, err := decodePayload(payload)
+	if _, ok := err.(errUnsupportedVersion); ok {
+		// The task was created by a later version of the application,
+		// which may still be being deployed.
+		log.Errorf(c, "delay: failed decoding task payload (will retry): %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
 		log.Errorf(c, "delay: failed decoding task payload: %v", err)
 		log.Warningf(c, "delay: dropping task")
 		return
 	}
 
-	f := funcs[inv.Key]
+	f := funcs[key]
 	if f == nil {
-		log.Errorf(c, "delay: no func with key %q found", inv.Key)
+		log.Errorf(c, "delay: no func with key %q found", key)
 		log.Warningf(c, "delay: dropping task")
 		return
 	}
 
 	ft := f.fv.Type()
-	in := []reflect.Value{reflect.ValueOf(c)}
-	for _, arg := range inv.Args {
-		var v reflect.Value
-		if arg != nil {
-			v = reflect.ValueOf(arg)
-		} else {
-			// Task was passed a nil argument, so we must construct
-			// the zero value for the argument here.
-			n := len(in) // we're constructing the nth argument
-			var at reflect.Type
-			if !ft.IsVariadic() || n < ft.NumIn()-1 {
-				at = ft.In(n)
-			} else {
-				at = ft.In(ft.NumIn() - 1).Elem()
-			}
-			v = reflect.Zero(at)
-		}
-		in = append(in, v)
+	args, err := decodeArgs(ft)
+	if err != nil {
+		log.Errorf(c, "delay: failed decoding arguments of func %q: %v", key, err)
+		log.Warningf(c, "delay: dropping task")
+		return
 	}
+	in := append([]reflect.Value{reflect.ValueOf(c)}, args...)
 	out := f.fv.Call(in)
 
-	if n := ft.NumOut(); n > 0 && ft.Out(n-1) == errorType {
-		if errv := out[n-1]; !errv.IsNil() {
-			log.Errorf(c, "delay: func failed (will retry): %v", errv.Interface())
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	n := ft.NumOut()
+	if n == 0 || ft.Out(n-1) != errorType || out[n-1].IsNil() {
+		return
+	}
+	ferr := out[n-1].Interface().(error)
+	p := f.deadLetterPolicy()
+	if !p.giveUp(ferr, headers) {
+		log.Errorf(c, "delay: func failed (will retry): %v", ferr)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	log.Errorf(c, "delay: func failed (will not retry): %v", ferr)
+	if p == nil || p.Handle == nil {
+		return
+	}
+	d := &DeadLetter{
+		Key:       key,
+		Payload:   payload,
+		Err:       ferr,
+		Permanent: IsPermanent(ferr),
+		Headers:   headers,
+	}
+	for _, a := range args {
+		d.Args = append(d.Args, a.Interface())
+	}
+	if err := p.Handle(c, d); err != nil {
+		log.Errorf(c, "delay: dead letter handler failed (will retry): %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
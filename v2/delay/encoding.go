@@ -0,0 +1,253 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Encoding is the way the arguments of a function's invocations are encoded
+// in the payloads of their tasks.
+//
+// GobEncoding, the default, encodes arguments with encoding/gob. It supports
+// almost any argument type, but a task can no longer be decoded once the
+// types of its arguments are renamed or moved to another package, and the
+// concrete types held by interface arguments must be registered with
+// gob.Register.
+//
+// JSONEncoding and ProtoEncoding encode each argument with encoding/json or
+// as a protocol buffer, inside a versioned envelope. They depend only on the
+// arguments' fields, so tasks survive refactors that keep the fields
+// compatible. Interface arguments are not supported, and with ProtoEncoding
+// every argument must be a proto.Message.
+//
+// Whatever the encoding of a function, its tasks are decoded according to
+// how their payloads were encoded. So a function may be changed from
+// GobEncoding to another encoding while tasks encoded by an earlier version
+// of the application are still queued: they still run, provided their
+// argument types can still be decoded by gob.
+type Encoding int
+
+const (
+	GobEncoding Encoding = iota
+	JSONEncoding
+	ProtoEncoding
+)
+
+var encodingNames = map[Encoding]string{
+	GobEncoding:   "gob",
+	JSONEncoding:  "json",
+	ProtoEncoding: "proto",
+}
+
+func (e Encoding) String() string {
+	if s, ok := encodingNames[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// envelopeMagic prefixes payloads that hold an envelope. A gob stream never
+// starts with a zero byte, which would be the length of an empty message,
+// so payloads without the prefix are gob-encoded invocations.
+var envelopeMagic = []byte("\x00delay")
+
+// envelopeVersion is the version of the envelope format written by Task.
+const envelopeVersion = 1
+
+// envelope is an invocation whose arguments are encoded individually.
+type envelope struct {
+	Version  int               `json:"v"`
+	Key      string            `json:"key"`
+	Encoding string            `json:"encoding"`
+	Args     []json.RawMessage `json:"args"`
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// WithEncoding sets the encoding of f's invocations and returns f. It panics
+// if f has an argument that the encoding does not support. Like
+// MustRegister, WithEncoding must be called in a global scope:
+//
+//	var laterFunc = delay.MustRegister("key", myFunc).WithEncoding(delay.JSONEncoding)
+func (f *Function) WithEncoding(e Encoding) *Function {
+	if err := checkEncoding(f.fv.Type(), e); err != nil {
+		panic(err)
+	}
+	f.enc = e
+	return f
+}
+
+// checkEncoding returns an error if the arguments of the function type ft
+// cannot be encoded with e.
+func checkEncoding(ft reflect.Type, e Encoding) error {
+	if _, ok := encodingNames[e]; !ok {
+		return fmt.Errorf("delay: unknown encoding %v", e)
+	}
+	if e == GobEncoding {
+		return nil
+	}
+	for i := 1; i < ft.NumIn(); i++ {
+		at := ft.In(i)
+		if i == ft.NumIn()-1 && ft.IsVariadic() {
+			at = at.Elem()
+		}
+		if at.Kind() == reflect.Interface {
+			return fmt.Errorf("delay: argument %d has interface type %v, which %v encoding does not support", i, at, e)
+		}
+		if e == ProtoEncoding && !at.Implements(protoMessageType) {
+			return fmt.Errorf("delay: argument %d has type %v, which is not a proto.Message", i, at)
+		}
+	}
+	return nil
+}
+
+// encode returns the payload of an invocation of f with the given
+// arguments, which have been checked against f's type.
+func (f *Function) encode(args []interface{}) ([]byte, error) {
+	if f.enc == GobEncoding {
+		inv := invocation{
+			Key:  f.key,
+			Args: args,
+		}
+		buf := new(bytes.Buffer)
+		if err := gob.NewEncoder(buf).Encode(inv); err != nil {
+			return nil, fmt.Errorf("delay: gob encoding failed: %v", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	env := envelope{
+		Version:  envelopeVersion,
+		Key:      f.key,
+		Encoding: f.enc.String(),
+		Args:     make([]json.RawMessage, len(args)),
+	}
+	for i, arg := range args {
+		var v interface{} = arg
+		if f.enc == ProtoEncoding && arg != nil {
+			b, err := proto.Marshal(arg.(proto.Message))
+			if err != nil {
+				return nil, fmt.Errorf("delay: proto encoding of argument %d failed: %v", i+1, err)
+			}
+			v = b
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("delay: %v encoding of argument %d failed: %v", f.enc, i+1, err)
+		}
+		env.Args[i] = b
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("delay: encoding failed: %v", err)
+	}
+	return append(append([]byte{}, envelopeMagic...), b...), nil
+}
+
+// argType returns the type of the n'th argument of the function type ft,
+// counting the context as the zeroth.
+func argType(ft reflect.Type, n int) reflect.Type {
+	if !ft.IsVariadic() || n < ft.NumIn()-1 {
+		return ft.In(n)
+	}
+	return ft.In(ft.NumIn() - 1).Elem()
+}
+
+// errUnsupportedVersion is returned by decodePayload for envelopes written
+// by a later version of this package.
+type errUnsupportedVersion int
+
+func (e errUnsupportedVersion) Error() string {
+	return fmt.Sprintf("unsupported payload version %d", int(e))
+}
+
+// decodePayload decodes a task payload, encoded either as a gob invocation
+// or as an envelope. It returns the function's key and a function that
+// decodes the arguments for the function type ft.
+func decodePayload(payload []byte) (key string, args func(ft reflect.Type) ([]reflect.Value, error), err error) {
+	if !bytes.HasPrefix(payload, envelopeMagic) {
+		var inv invocation
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&inv); err != nil {
+			return "", nil, err
+		}
+		return inv.Key, inv.values, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(payload[len(envelopeMagic):], &env); err != nil {
+		return "", nil, err
+	}
+	if env.Version > envelopeVersion {
+		return "", nil, errUnsupportedVersion(env.Version)
+	}
+	return env.Key, env.values, nil
+}
+
+// values returns the arguments of a gob-encoded invocation.
+func (inv *invocation) values(ft reflect.Type) ([]reflect.Value, error) {
+	var in []reflect.Value
+	for _, arg := range inv.Args {
+		var v reflect.Value
+		if arg != nil {
+			v = reflect.ValueOf(arg)
+		} else {
+			// Task was passed a nil argument, so we must construct
+			// the zero value for the argument here.
+			v = reflect.Zero(argType(ft, len(in)+1))
+		}
+		in = append(in, v)
+	}
+	return in, nil
+}
+
+// values returns the arguments of an invocation encoded in an envelope.
+func (env *envelope) values(ft reflect.Type) ([]reflect.Value, error) {
+	var in []reflect.Value
+	for i, raw := range env.Args {
+		if i+1 >= ft.NumIn() && !ft.IsVariadic() {
+			return nil, fmt.Errorf("too many arguments: %d > %d", len(env.Args)+1, ft.NumIn())
+		}
+		at := argType(ft, i+1)
+		v := reflect.New(at)
+		switch env.Encoding {
+		case "json":
+			if err := json.Unmarshal(raw, v.Interface()); err != nil {
+				return nil, fmt.Errorf("argument %d: %v", i+1, err)
+			}
+		case "proto":
+			var b []byte
+			if err := json.Unmarshal(raw, &b); err != nil {
+				return nil, fmt.Errorf("argument %d: %v", i+1, err)
+			}
+			if b != nil {
+				if at.Kind() != reflect.Ptr {
+					return nil, fmt.Errorf("argument %d: %v is not a pointer to a message", i+1, at)
+				}
+				v.Elem().Set(reflect.New(at.Elem()))
+				m, ok := v.Elem().Interface().(proto.Message)
+				if !ok {
+					return nil, fmt.Errorf("argument %d: %v is not a proto.Message", i+1, at)
+				}
+				if err := proto.Unmarshal(b, m); err != nil {
+					return nil, fmt.Errorf("argument %d: %v", i+1, err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown encoding %q", env.Encoding)
+		}
+		in = append(in, v.Elem())
+	}
+	if minArgs := ft.NumIn(); len(in)+1 < minArgs && !(ft.IsVariadic() && len(in)+2 == minArgs) {
+		return nil, fmt.Errorf("too few arguments: %d < %d", len(in)+1, minArgs)
+	}
+	return in, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	basepb "google.golang.org/appengine/v2/internal/base"
+)
+
+type jsonArg struct {
+	Name  string
+	Count int
+}
+
+var (
+	jsonFArgs []interface{}
+	jsonF     = func(c context.Context, a *jsonArg, b map[string]int, ns ...int) {
+		jsonFArgs = []interface{}{a, b, ns}
+	}
+	jsonRegister = MustRegister("jsonRegister", jsonF).WithEncoding(JSONEncoding)
+
+	protoFArgs []interface{}
+	protoF     = func(c context.Context, s *basepb.StringProto, n *basepb.Integer64Proto) {
+		protoFArgs = []interface{}{s, n}
+	}
+	protoRegister = MustRegister("protoRegister", protoF).WithEncoding(ProtoEncoding)
+)
+
+// runTask simulates the Task Queue service running a task with the given
+// payload.
+func runTask(c context.Context, payload []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(payload))
+	rw := httptest.NewRecorder()
+	runFunc(c, rw, req)
+	return rw
+}
+
+func TestJSONEncoding(t *testing.T) {
+	c := newFakeContext()
+	tests := []struct {
+		args []interface{}
+		want []interface{}
+	}{
+		{
+			args: []interface{}{&jsonArg{"a", 1}, map[string]int{"b": 2}, 3, 4},
+			want: []interface{}{&jsonArg{"a", 1}, map[string]int{"b": 2}, []int{3, 4}},
+		},
+		{
+			args: []interface{}{nil, nil},
+			want: []interface{}{(*jsonArg)(nil), map[string]int(nil), []int{}},
+		},
+	}
+	for _, tc := range tests {
+		task, err := jsonRegister.Task(tc.args...)
+		if err != nil {
+			t.Fatalf("Task(%v): %v", tc.args, err)
+		}
+		if !bytes.HasPrefix(task.Payload, envelopeMagic) || !bytes.Contains(task.Payload, []byte(`"encoding":"json"`)) {
+			t.Errorf("Task(%v) payload %q is not a JSON envelope", tc.args, task.Payload)
+		}
+		jsonFArgs = nil
+		runTask(c.ctx, task.Payload)
+		if !reflect.DeepEqual(jsonFArgs, tc.want) {
+			t.Errorf("Task(%v) ran with %#v, want %#v", tc.args, jsonFArgs, tc.want)
+		}
+	}
+}
+
+func TestProtoEncoding(t *testing.T) {
+	c := newFakeContext()
+	task, err := protoRegister.Task(&basepb.StringProto{Value: proto.String("hi")}, (*basepb.Integer64Proto)(nil))
+	if err != nil {
+		t.Fatalf("Task: %v", err)
+	}
+	runTask(c.ctx, task.Payload)
+	if len(protoFArgs) != 2 {
+		t.Fatalf("function ran with %v, want 2 arguments", protoFArgs)
+	}
+	if s := protoFArgs[0].(*basepb.StringProto); s.GetValue() != "hi" {
+		t.Errorf("first argument = %v, want %q", s, "hi")
+	}
+	if n := protoFArgs[1].(*basepb.Integer64Proto); n != nil {
+		t.Errorf("second argument = %v, want nil", n)
+	}
+}
+
+func TestLegacyGobPayload(t *testing.T) {
+	// A function whose encoding has changed still runs gob-encoded tasks
+	// created before the change.
+	c := newFakeContext()
+	f := &Function{fv: jsonRegister.fv, key: jsonRegister.key}
+	task, err := f.Task(&jsonArg{"old", 7}, map[string]int{"x": 1})
+	if err != nil {
+		t.Fatalf("Task: %v", err)
+	}
+	if bytes.HasPrefix(task.Payload, envelopeMagic) {
+		t.Fatalf("gob payload %q has the envelope prefix", task.Payload)
+	}
+	jsonFArgs = nil
+	runTask(c.ctx, task.Payload)
+	want := []interface{}{&jsonArg{"old", 7}, map[string]int{"x": 1}, []int{}}
+	if !reflect.DeepEqual(jsonFArgs, want) {
+		t.Errorf("ran with %#v, want %#v", jsonFArgs, want)
+	}
+}
+
+func TestEnvelopeErrors(t *testing.T) {
+	c := newFakeContext()
+	tests := []struct {
+		payload  string
+		wantCode int
+	}{
+		// A later version is retried, in case it comes from a newer
+		// deployment.
+		{`{"v":2,"key":"jsonRegister","encoding":"json","args":[]}`, http.StatusInternalServerError},
+		// Undecodable arguments are dropped.
+		{`{"v":1,"key":"jsonRegister","encoding":"json","args":["x", {}]}`, http.StatusOK},
+		{`{"v":1,"key":"jsonRegister","encoding":"yaml","args":[]}`, http.StatusOK},
+		{`{"v":1,"key":"jsonRegister","encoding":"json","args":[]}`, http.StatusOK},
+		{`{"v":1,"key":"protoRegister","encoding":"proto","args":[null,null,null]}`, http.StatusOK},
+	}
+	for _, tc := range tests {
+		jsonFArgs, protoFArgs = nil, nil
+		rw := runTask(c.ctx, append(append([]byte{}, envelopeMagic...), tc.payload...))
+		if rw.Code != tc.wantCode {
+			t.Errorf("payload %s: status %d, want %d", tc.payload, rw.Code, tc.wantCode)
+		}
+		if jsonFArgs != nil || protoFArgs != nil {
+			t.Errorf("payload %s: function ran", tc.payload)
+		}
+	}
+}
+
+func TestWithEncodingErrors(t *testing.T) {
+	tests := []struct {
+		fn      interface{}
+		enc     Encoding
+		wantErr string
+	}{
+		{func(context.Context, CustomInterface) {}, JSONEncoding, "interface type"},
+		{func(context.Context, string) {}, ProtoEncoding, "not a proto.Message"},
+		{func(context.Context) {}, Encoding(9), "unknown encoding"},
+	}
+	for _, tc := range tests {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("WithEncoding(%v) on %T: panicked with %v, want %q", tc.enc, tc.fn, err, tc.wantErr)
+				}
+			}()
+			f, _ := registerFunction("unregistered", tc.fn)
+			f.WithEncoding(tc.enc)
+		}()
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package delay
+
+import (
+	"context"
+
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+// Function1 is a delayed function of one argument whose invocations are
+// type checked at compile time. It is created with Register1.
+//
+// A Function1 is registered, and its invocations are encoded, exactly like a
+// Function registered with MustRegister, so the two are interchangeable: a
+// task created by one runs the function registered by the other.
+type Function1[A any] struct {
+	f *Function
+}
+
+// Register1 declares a new function of one argument that can be called in a
+// deferred fashion. Like MustRegister, it requires the key to be globally
+// unique, panics if it is not, and must be called in a global scope.
+func Register1[A any](key string, fn func(c context.Context, a A) error) *Function1[A] {
+	return &Function1[A]{MustRegister(key, fn)}
+}
+
+// Call invokes the delayed function. The options opts configure its task.
+func (f *Function1[A]) Call(c context.Context, a A, opts ...CallOption) error {
+	return f.f.Call(c, append([]interface{}{a}, optionArgs(opts)...)...)
+}
+
+// Task creates a Task that will invoke the function. See Function.Task.
+func (f *Function1[A]) Task(a A) (*taskqueue.Task, error) {
+	return f.f.Task(a)
+}
+
+// Invocation returns an invocation of the function for CallMulti.
+func (f *Function1[A]) Invocation(a A, opts ...CallOption) Invocation {
+	return Invocation{Func: f.f, Args: []interface{}{a}, Options: opts}
+}
+
+// WithEncoding sets the encoding of f's invocations and returns f. See
+// Function.WithEncoding.
+func (f *Function1[A]) WithEncoding(e Encoding) *Function1[A] {
+	f.f.WithEncoding(e)
+	return f
+}
+
+// WithDeadLetterPolicy sets the dead letter policy of f and returns f. See
+// Function.WithDeadLetterPolicy.
+func (f *Function1[A]) WithDeadLetterPolicy(p *DeadLetterPolicy) *Function1[A] {
+	f.f.WithDeadLetterPolicy(p)
+	return f
+}
+
+// Function2 is a delayed function of two arguments whose invocations are
+// type checked at compile time. It is created with Register2.
+type Function2[A, B any] struct {
+	f *Function
+}
+
+// Register2 declares a new function of two arguments that can be called in a
+// deferred fashion. See Register1.
+func Register2[A, B any](key string, fn func(c context.Context, a A, b B) error) *Function2[A, B] {
+	return &Function2[A, B]{MustRegister(key, fn)}
+}
+
+// Call invokes the delayed function. The options opts configure its task.
+func (f *Function2[A, B]) Call(c context.Context, a A, b B, opts ...CallOption) error {
+	return f.f.Call(c, append([]interface{}{a, b}, optionArgs(opts)...)...)
+}
+
+// Task creates a Task that will invoke the function. See Function.Task.
+func (f *Function2[A, B]) Task(a A, b B) (*taskqueue.Task, error) {
+	return f.f.Task(a, b)
+}
+
+// Invocation returns an invocation of the function for CallMulti.
+func (f *Function2[A, B]) Invocation(a A, b B, opts ...CallOption) Invocation {
+	return Invocation{Func: f.f, Args: []interface{}{a, b}, Options: opts}
+}
+
+// WithEncoding sets the encoding of f's invocations and returns f. See
+// Function.WithEncoding.
+func (f *Function2[A, B]) WithEncoding(e Encoding) *Function2[A, B] {
+	f.f.WithEncoding(e)
+	return f
+}
+
+// WithDeadLetterPolicy sets the dead letter policy of f and returns f. See
+// Function.WithDeadLetterPolicy.
+func (f *Function2[A, B]) WithDeadLetterPolicy(p *DeadLetterPolicy) *Function2[A, B] {
+	f.f.WithDeadLetterPolicy(p)
+	return f
+}
+
+// Function3 is a delayed function of three arguments whose invocations are
+// type checked at compile time. It is created with Register3.
+type Function3[A, B, C any] struct {
+	f *Function
+}
+
+// Register3 declares a new function of three arguments that can be called in
+// a deferred fashion. See Register1.
+func Register3[A, B, C any](key string, fn func(c context.Context, a A, b B, cc C) error) *Function3[A, B, C] {
+	return &Function3[A, B, C]{MustRegister(key, fn)}
+}
+
+// Call invokes the delayed function. The options opts configure its task.
+func (f *Function3[A, B, C]) Call(c context.Context, a A, b B, cc C, opts ...CallOption) error {
+	return f.f.Call(c, append([]interface{}{a, b, cc}, optionArgs(opts)...)...)
+}
+
+// Task creates a Task that will invoke the function. See Function.Task.
+func (f *Function3[A, B, C]) Task(a A, b B, cc C) (*taskqueue.Task, error) {
+	return f.f.Task(a, b, cc)
+}
+
+// Invocation returns an invocation of the function for CallMulti.
+func (f *Function3[A, B, C]) Invocation(a A, b B, cc C, opts ...CallOption) Invocation {
+	return Invocation{Func: f.f, Args: []interface{}{a, b, cc}, Options: opts}
+}
+
+// WithEncoding sets the encoding of f's invocations and returns f. See
+// Function.WithEncoding.
+func (f *Function3[A, B, C]) WithEncoding(e Encoding) *Function3[A, B, C] {
+	f.f.WithEncoding(e)
+	return f
+}
+
+// WithDeadLetterPolicy sets the dead letter policy of f and returns f. See
+// Function.WithDeadLetterPolicy.
+func (f *Function3[A, B, C]) WithDeadLetterPolicy(p *DeadLetterPolicy) *Function3[A, B, C] {
+	f.f.WithDeadLetterPolicy(p)
+	return f
+}
+
+// optionArgs converts opts to arguments of Function.Call.
+func optionArgs(opts []CallOption) []interface{} {
+	args := make([]interface{}, len(opts))
+	for i, o := range opts {
+		args[i] = o
+	}
+	return args
+}
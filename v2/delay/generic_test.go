@@ -0,0 +1,116 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package delay
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"reflect"
+	"testing"
+
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+var (
+	genericMsg   string
+	genericTally int
+	genericCount int
+
+	generic1 = Register1("generic1", func(c context.Context, msg string) error {
+		genericMsg = msg
+		return nil
+	})
+	generic2 = Register2("generic2", func(c context.Context, ct *CustomType, ci CustomInterface) error {
+		a, b := 2, 3
+		if ct != nil {
+			a = ct.N
+		}
+		if ci != nil {
+			b = ci.N()
+		}
+		genericTally += a + b
+		return nil
+	})
+	generic3 = Register3("generic3", func(c context.Context, a, b int, s []string) error {
+		genericCount = a + b + len(s)
+		return nil
+	})
+)
+
+func TestGenericFunctions(t *testing.T) {
+	c := newFakeContext()
+
+	task, err := generic1.Task("hello")
+	if err != nil {
+		t.Fatalf("generic1.Task: %v", err)
+	}
+	runTask(c.ctx, task.Payload)
+	if genericMsg != "hello" {
+		t.Errorf("genericMsg: got %q, want %q", genericMsg, "hello")
+	}
+
+	genericTally = 0
+	task, err = generic2.Task(&CustomType{N: 11}, CustomImpl(13))
+	if err != nil {
+		t.Fatalf("generic2.Task: %v", err)
+	}
+	runTask(c.ctx, task.Payload)
+	task, err = generic2.Task(nil, nil)
+	if err != nil {
+		t.Fatalf("generic2.Task with nil arguments: %v", err)
+	}
+	runTask(c.ctx, task.Payload)
+	if want := 11 + 13 + 2 + 3; genericTally != want {
+		t.Errorf("genericTally: got %d, want %d", genericTally, want)
+	}
+
+	task, err = generic3.Task(1, 2, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("generic3.Task: %v", err)
+	}
+	runTask(c.ctx, task.Payload)
+	if genericCount != 6 {
+		t.Errorf("genericCount: got %d, want 6", genericCount)
+	}
+}
+
+func TestGenericFunctionLegacyPayload(t *testing.T) {
+	// A payload encoded by Function.Task, as queued by an earlier version
+	// of an application, runs the typed function registered under its key.
+	c := newFakeContext()
+	var buf bytes.Buffer
+	inv := invocation{Key: "generic1", Args: []interface{}{"from an old task"}}
+	if err := gob.NewEncoder(&buf).Encode(inv); err != nil {
+		t.Fatal(err)
+	}
+	runTask(c.ctx, buf.Bytes())
+	if genericMsg != "from an old task" {
+		t.Errorf("genericMsg: got %q, want %q", genericMsg, "from an old task")
+	}
+}
+
+func TestGenericCallOptions(t *testing.T) {
+	c := newFakeContext()
+	var queue string
+	taskqueueAdder = func(_ context.Context, tk *taskqueue.Task, q string) (*taskqueue.Task, error) {
+		queue = q
+		return tk, nil
+	}
+	if err := generic2.Call(c.ctx, &CustomType{N: 1}, nil, WithQueue("typed")); err != nil {
+		t.Fatalf("generic2.Call: %v", err)
+	}
+	if queue != "typed" {
+		t.Errorf("queue: got %q, want %q", queue, "typed")
+	}
+
+	inv := generic1.Invocation("x", WithName("n"))
+	if inv.Func != generic1.f || !reflect.DeepEqual(inv.Args, []interface{}{"x"}) || len(inv.Options) != 1 {
+		t.Errorf("Invocation = %+v", inv)
+	}
+}
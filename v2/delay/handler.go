@@ -0,0 +1,53 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"net/http"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+// taskPath is the path of the tasks created by Function.Task.
+var taskPath = path
+
+// SetPath sets the URL path of the tasks that invoke delayed functions, for
+// applications that serve NewHandler at a path of their own instead of
+// relying on the handler registered with http.DefaultServeMux at
+// "/_ah/queue/go/delay". The new path applies only to tasks created after
+// the call, so SetPath should be called during initialization, and the
+// default handler must be kept while tasks created with the old path may
+// still be queued.
+func SetPath(p string) {
+	taskPath = p
+}
+
+// Middleware wraps the handler of delayed function invocations, for example
+// to check the requests.
+type Middleware func(next http.Handler) http.Handler
+
+// NewHandler returns a handler that runs delayed functions, for applications
+// that route requests with their own router. The middleware mw wrap the
+// handler in order, so the first is the outermost. For example:
+//
+//	delay.SetPath("/tasks/delay")
+//	router.Handle("/tasks/delay", delay.NewHandler(delay.RequireTaskQueue))
+func NewHandler(mw ...Middleware) http.Handler {
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		runFunc(appengine.NewContext(req), w, req)
+	})
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// RequireTaskQueue is a Middleware that rejects requests that were not made
+// by the Task Queue service, with status 403 Forbidden. It is the same as
+// taskqueue.RequireTaskQueue.
+func RequireTaskQueue(next http.Handler) http.Handler {
+	return taskqueue.RequireTaskQueue(next)
+}
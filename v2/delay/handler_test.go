@@ -0,0 +1,71 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSetPath(t *testing.T) {
+	defer SetPath(path)
+	SetPath("/tasks/delay")
+	task, err := regRegister.Task("x")
+	if err != nil {
+		t.Fatalf("Task: %v", err)
+	}
+	if task.Path != "/tasks/delay" {
+		t.Errorf("task path: got %q, want %q", task.Path, "/tasks/delay")
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	task, err := regRegister.Task("handled")
+	if err != nil {
+		t.Fatalf("Task: %v", err)
+	}
+
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+	h := NewHandler(mw("outer"), RequireTaskQueue, mw("inner"))
+
+	tests := []struct {
+		queue     string
+		wantCode  int
+		wantRuns  int
+		wantOrder []string
+	}{
+		{"", http.StatusForbidden, 0, []string{"outer"}},
+		{"default", http.StatusOK, 1, []string{"outer", "inner"}},
+	}
+	for _, tc := range tests {
+		regFRuns, regFMsg, order = 0, "", nil
+		req := httptest.NewRequest("POST", "/tasks/delay", bytes.NewReader(task.Payload))
+		if tc.queue != "" {
+			req.Header.Set("X-AppEngine-QueueName", tc.queue)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		if rw.Code != tc.wantCode {
+			t.Errorf("queue %q: status %d, want %d", tc.queue, rw.Code, tc.wantCode)
+		}
+		if regFRuns != tc.wantRuns {
+			t.Errorf("queue %q: function ran %d times, want %d", tc.queue, regFRuns, tc.wantRuns)
+		}
+		if !reflect.DeepEqual(order, tc.wantOrder) {
+			t.Errorf("queue %q: middleware ran in order %v, want %v", tc.queue, order, tc.wantOrder)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+// A CallOption configures the task that invokes a delayed function, when
+// passed to Function.Call or CallMulti.
+type CallOption interface {
+	apply(o *callOptions)
+}
+
+// callOptions is the configuration built by a list of CallOptions.
+type callOptions struct {
+	queue string
+	task  *taskqueue.Task
+}
+
+type callOptionFunc func(o *callOptions)
+
+func (f callOptionFunc) apply(o *callOptions) { f(o) }
+
+// WithQueue adds the task to the named queue instead of the default queue.
+func WithQueue(name string) CallOption {
+	return callOptionFunc(func(o *callOptions) { o.queue = name })
+}
+
+// WithName names the task. A task with the same name as one added earlier is
+// rejected with taskqueue.ErrTaskAlreadyAdded, so naming tasks after the work
+// they do prevents the same work from being queued twice.
+func WithName(name string) CallOption {
+	return callOptionFunc(func(o *callOptions) { o.task.Name = name })
+}
+
+// WithDelay delays the function's execution by at least d.
+func WithDelay(d time.Duration) CallOption {
+	return callOptionFunc(func(o *callOptions) { o.task.Delay = d })
+}
+
+// WithETA delays the function's execution until at least t.
+func WithETA(t time.Time) CallOption {
+	return callOptionFunc(func(o *callOptions) { o.task.ETA = t })
+}
+
+// WithRetryOptions sets the task's retry options, overriding those of the
+// queue.
+func WithRetryOptions(r *taskqueue.RetryOptions) CallOption {
+	return callOptionFunc(func(o *callOptions) { o.task.RetryOptions = r })
+}
+
+// WithHeader adds an HTTP header to the request that runs the function. To
+// run the function on another version or service, set the "Host" header.
+func WithHeader(key, value string) CallOption {
+	return callOptionFunc(func(o *callOptions) {
+		if o.task.Header == nil {
+			o.task.Header = make(http.Header)
+		}
+		o.task.Header.Add(key, value)
+	})
+}
+
+// applyOptions applies opts to t and returns the queue that t should be
+// added to.
+func applyOptions(t *taskqueue.Task, opts []CallOption) string {
+	o := callOptions{queue: queue, task: t}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o.queue
+}
+
+// splitOptions separates the trailing CallOptions from the arguments of a
+// call.
+func splitOptions(args []interface{}) ([]interface{}, []CallOption) {
+	n := len(args)
+	for n > 0 {
+		if _, ok := args[n-1].(CallOption); !ok {
+			break
+		}
+		n--
+	}
+	var opts []CallOption
+	for _, a := range args[n:] {
+		opts = append(opts, a.(CallOption))
+	}
+	return args[:n], opts
+}
+
+// Invocation is an invocation of a delayed function, for CallMulti.
+type Invocation struct {
+	Func    *Function
+	Args    []interface{}
+	Options []CallOption
+}
+
+// CallMulti invokes several delayed functions, adding their tasks with as
+// few calls to taskqueue.AddMulti as possible: one for each queue and batch
+// of 100 tasks. The options opts apply to every invocation, before the
+// invocation's own options.
+//
+// If any invocation cannot be encoded or its task cannot be added, an
+// appengine.MultiError is returned, with an error for each invocation in
+// order. If an invocation cannot be encoded, no task is added.
+func CallMulti(c context.Context, invs []Invocation, opts ...CallOption) error {
+	me, any := make(appengine.MultiError, len(invs)), false
+	tasks := make(map[string][]*taskqueue.Task)
+	indexes := make(map[string][]int)
+	var queues []string
+	for i, inv := range invs {
+		t, err := inv.Func.Task(inv.Args...)
+		if err != nil {
+			me[i], any = err, true
+			continue
+		}
+		q := applyOptions(t, append(append([]CallOption{}, opts...), inv.Options...))
+		if _, ok := tasks[q]; !ok {
+			queues = append(queues, q)
+		}
+		tasks[q] = append(tasks[q], t)
+		indexes[q] = append(indexes[q], i)
+	}
+	if any {
+		return me
+	}
+
+	for _, q := range queues {
+		qtasks, qindexes := tasks[q], indexes[q]
+		for len(qtasks) > 0 {
+			n := len(qtasks)
+			if n > maxBatch {
+				n = maxBatch
+			}
+			if _, err := taskqueueMultiAdder(c, qtasks[:n], q); err != nil {
+				any = true
+				bme, ok := err.(appengine.MultiError)
+				for j, i := range qindexes[:n] {
+					if ok {
+						me[i] = bme[j]
+					} else {
+						me[i] = err
+					}
+				}
+			}
+			qtasks, qindexes = qtasks[n:], qindexes[n:]
+		}
+	}
+	if any {
+		return me
+	}
+	return nil
+}
+
+// maxBatch is the largest number of tasks added by one call to
+// taskqueue.AddMulti.
+const maxBatch = 100
+
+var taskqueueMultiAdder = taskqueue.AddMulti // for testing
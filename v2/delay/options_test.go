@@ -0,0 +1,145 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package delay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+func TestCallOptions(t *testing.T) {
+	c := newFakeContext()
+	var task *taskqueue.Task
+	var queue string
+	taskqueueAdder = func(_ context.Context, tk *taskqueue.Task, q string) (*taskqueue.Task, error) {
+		task, queue = tk, q
+		return tk, nil
+	}
+
+	eta := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	retry := &taskqueue.RetryOptions{RetryLimit: 3}
+	err := regRegister.Call(c.ctx, "msg",
+		WithQueue("background"),
+		WithName("task-1"),
+		WithETA(eta),
+		WithRetryOptions(retry),
+		WithHeader("Host", "worker.example.com"),
+		WithHeader("X-Custom", "a"),
+	)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if queue != "background" {
+		t.Errorf("queue: got %q, want %q", queue, "background")
+	}
+	want := &taskqueue.Task{
+		Path:         path,
+		Payload:      task.Payload,
+		Name:         "task-1",
+		ETA:          eta,
+		RetryOptions: retry,
+		Header: http.Header{
+			"Host":     {"worker.example.com"},
+			"X-Custom": {"a"},
+		},
+	}
+	if !reflect.DeepEqual(task, want) {
+		t.Errorf("task:\ngot  %+v\nwant %+v", task, want)
+	}
+
+	// Options are not mistaken for arguments.
+	regFRuns, regFMsg = 0, ""
+	if err := regRegister.Call(c.ctx, "msg", WithDelay(time.Minute)); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if task.Delay != time.Minute || queue != "" {
+		t.Errorf("task delay %v on queue %q, want %v on the default queue", task.Delay, queue, time.Minute)
+	}
+	runTask(c.ctx, task.Payload)
+	if regFRuns != 1 || regFMsg != "msg" {
+		t.Errorf("function ran %d times with %q, want once with %q", regFRuns, regFMsg, "msg")
+	}
+}
+
+func TestCallMulti(t *testing.T) {
+	c := newFakeContext()
+	added := make(map[string][]*taskqueue.Task)
+	errFull := errors.New("queue full")
+	var batches []int
+	taskqueueMultiAdder = func(_ context.Context, tasks []*taskqueue.Task, q string) ([]*taskqueue.Task, error) {
+		added[q] = append(added[q], tasks...)
+		batches = append(batches, len(tasks))
+		if q == "full" {
+			return nil, appengine.MultiError{nil, errFull}
+		}
+		return tasks, nil
+	}
+	defer func() { taskqueueMultiAdder = taskqueue.AddMulti }()
+
+	invs := []Invocation{
+		{Func: regRegister, Args: []interface{}{"a"}},
+		{Func: regRegister, Args: []interface{}{"b"}, Options: []CallOption{WithQueue("other")}},
+		{Func: regRegister, Args: []interface{}{"c"}, Options: []CallOption{WithName("c")}},
+	}
+	if err := CallMulti(c.ctx, invs, WithDelay(time.Second)); err != nil {
+		t.Fatalf("CallMulti: %v", err)
+	}
+	if len(added[""]) != 2 || len(added["other"]) != 1 {
+		t.Fatalf("added %d tasks to the default queue and %d to other, want 2 and 1", len(added[""]), len(added["other"]))
+	}
+	if tk := added[""][1]; tk.Name != "c" || tk.Delay != time.Second {
+		t.Errorf("third task has name %q and delay %v, want %q and %v", tk.Name, tk.Delay, "c", time.Second)
+	}
+
+	// Errors are reported for each invocation.
+	invs = []Invocation{
+		{Func: regRegister, Args: []interface{}{"a"}},
+		{Func: regRegister, Args: []interface{}{"b"}, Options: []CallOption{WithQueue("full")}},
+		{Func: regRegister, Args: []interface{}{"c"}, Options: []CallOption{WithQueue("full")}},
+	}
+	err := CallMulti(c.ctx, invs)
+	if want := (appengine.MultiError{nil, nil, errFull}); !reflect.DeepEqual(err, want) {
+		t.Errorf("CallMulti: got %v, want %v", err, want)
+	}
+
+	// Invalid invocations prevent any task from being added.
+	added = make(map[string][]*taskqueue.Task)
+	invs = []Invocation{
+		{Func: regRegister, Args: []interface{}{"a"}},
+		{Func: regRegister, Args: []interface{}{1}},
+	}
+	err = CallMulti(c.ctx, invs)
+	if me, ok := err.(appengine.MultiError); !ok || me[0] != nil || me[1] == nil {
+		t.Errorf("CallMulti with a bad argument: got %v, want an error for the second invocation", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("CallMulti with a bad argument added tasks: %v", added)
+	}
+
+	// Large invocations are added in batches, with errors mapped back to
+	// the invocations.
+	batches = nil
+	invs = nil
+	for i := 0; i < 250; i++ {
+		invs = append(invs, Invocation{Func: regRegister, Args: []interface{}{"x"}})
+	}
+	invs[120].Options = []CallOption{WithQueue("full")}
+	invs[130].Options = []CallOption{WithQueue("full")}
+	err = CallMulti(c.ctx, invs)
+	if want := []int{100, 100, 48, 2}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("CallMulti added batches of %v tasks, want %v", batches, want)
+	}
+	me, ok := err.(appengine.MultiError)
+	if !ok || len(me) != 250 || me[130] != errFull || me[120] != nil || me[0] != nil {
+		t.Errorf("CallMulti of 250 invocations: got %v, want only invocation 130 to fail", err)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+/*
+Package workflow chains delayed functions into simple workflows: sequences
+of steps that each receive the result of the previous one, and fan-outs that
+run a step for many inputs in parallel and pass all the results to a final
+step once every branch has completed.
+
+A step is a function of the form
+
+	func(c context.Context, in In) (Out, error)
+
+registered with MustRegister in a global scope, like a delayed function.
+Each step runs in its own task queue task. Inputs and results are encoded
+as JSON, so they must be JSON-serializable, and a step's In type must be
+able to decode the previous step's Out.
+
+	var (
+		fetch  = workflow.MustRegister("fetch", fetchPage)     // string -> Page
+		index  = workflow.MustRegister("index", indexPage)     // Page -> int
+		report = workflow.MustRegister("report", reportPages) // []Page -> bool
+	)
+
+	// Fetch a page, then index it.
+	err := workflow.Chain(ctx, "https://example.com/", fetch, index)
+
+	// Fetch many pages, then report on them all.
+	err = workflow.FanOut(ctx, urls, fetch, report)
+
+A step that returns an error is retried by the task queue, so steps should
+be idempotent. Returning delay.Permanent(err) stops the workflow.
+
+FanOut keeps its state in datastore entities of kind FanInKind, with the
+branch results in child entities of kind ResultKind. The fan-in entity is
+created in a transaction that also adds the tasks of the branches, so a
+FanOut that fails starts no branch. The tasks are added with
+taskqueue.AddMultiSpill: with more than taskqueue.MaxTransactionalTasks
+branches, they are carried by a spill task, so the application must serve
+taskqueue.SpillHandler and the inputs must fit in one task payload.
+
+Each branch records its result and decrements the count of remaining
+branches in a transaction on the fan-in entity's group, which also adds the
+task of the final step transactionally when the count reaches zero. The
+entities are deleted once the final step has succeeded. As all the branches
+write to one entity group, fan-ins of more than a few hundred branches are
+slow.
+*/
+package workflow // import "google.golang.org/appengine/v2/delay/workflow"
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"google.golang.org/appengine/v2/datastore"
+	"google.golang.org/appengine/v2/delay"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+// Datastore kinds used by FanOut.
+const (
+	// FanInKind is the kind of the entities that count the branches of a
+	// fan-out that have not completed.
+	FanInKind = "_WorkflowFanIn"
+	// ResultKind is the kind of the entities that hold the results of the
+	// branches of a fan-out.
+	ResultKind = "_WorkflowResult"
+)
+
+// txOptions are the options of the transactions on fan-in entities. All the
+// branches of a fan-out complete in transactions on the same entity group,
+// so conflicts are expected and retried with a backoff.
+var txOptions = &datastore.TransactionOptions{
+	Attempts: 5,
+	Backoff:  &datastore.Backoff{Initial: 100 * time.Millisecond, Max: 2 * time.Second},
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Step is a registered step of a workflow.
+type Step struct {
+	key string
+	fv  reflect.Value
+	in  reflect.Type
+}
+
+// steps is the registry of all steps.
+var steps = make(map[string]*Step)
+
+// MustRegister declares a new step. The function fn must have the signature
+// func(context.Context, In) (Out, error) for some types In and Out. The key
+// must be unique among steps.
+//
+// MustRegister must be called in a global scope, and panics if fn does not
+// have the right signature or the key is already in use.
+func MustRegister(key string, fn interface{}) *Step {
+	fv := reflect.ValueOf(fn)
+	t := fv.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.In(0) != contextType || t.NumOut() != 2 || t.Out(1) != errorType {
+		panic(fmt.Errorf("workflow: step %q is %v, not func(context.Context, In) (Out, error)", key, t))
+	}
+	if _, ok := steps[key]; ok {
+		panic(fmt.Errorf("workflow: multiple steps registered for %q", key))
+	}
+	s := &Step{key: key, fv: fv, in: t.In(1)}
+	steps[key] = s
+	return s
+}
+
+// job is an invocation of a step.
+type job struct {
+	// Step is the key of the step to run, and Input its JSON-encoded
+	// input.
+	Step  string
+	Input []byte
+	// Next are the keys of the steps to run after Step, in order.
+	Next []string
+
+	// FanIn is the key of the fan-in entity of the fan-out that the job
+	// belongs to. If Gather is false, the job is a branch, numbered
+	// Branch; otherwise it is the first step after the fan-in, whose input
+	// is the branch results.
+	FanIn  *datastore.Key
+	Branch int
+	Gather bool
+}
+
+// fanIn is the state of a fan-out.
+type fanIn struct {
+	Total     int
+	Remaining int
+	Next      []string `datastore:",noindex"`
+	Created   time.Time
+}
+
+// result is the result of a branch of a fan-out.
+type result struct {
+	Output []byte `datastore:",noindex"`
+}
+
+// runJob is the delayed function that runs jobs. It is registered in init
+// because run refers to it.
+var runJob *delay.Function
+
+func init() {
+	runJob = delay.MustRegister("google.golang.org/appengine/v2/delay/workflow.run", run)
+}
+
+func keys(s []*Step) []string {
+	k := make([]string, len(s))
+	for i, s := range s {
+		k[i] = s.key
+	}
+	return k
+}
+
+// Chain runs the steps in order, in separate tasks. The first step receives
+// input and each subsequent step the result of the previous one.
+func Chain(c context.Context, input interface{}, s ...*Step) error {
+	if len(s) == 0 {
+		return errors.New("workflow: no steps")
+	}
+	in, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("workflow: encoding input: %v", err)
+	}
+	return runJob.Call(c, &job{Step: s[0].key, Input: in, Next: keys(s[1:])})
+}
+
+// FanOut runs branch once for each element of inputs, which must be a slice,
+// in parallel tasks. Once every branch has completed, it runs the steps then
+// in order, as Chain does. The first of them receives a slice holding the
+// results of the branches, in the order of inputs.
+func FanOut(c context.Context, inputs interface{}, branch *Step, then ...*Step) error {
+	v := reflect.ValueOf(inputs)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("workflow: inputs are %T, not a slice", inputs)
+	}
+	if len(then) == 0 {
+		return errors.New("workflow: no steps after the fan-out")
+	}
+	if v.Len() == 0 {
+		return Chain(c, []struct{}{}, then...)
+	}
+
+	ins := make([][]byte, v.Len())
+	for i := range ins {
+		in, err := json.Marshal(v.Index(i).Interface())
+		if err != nil {
+			return fmt.Errorf("workflow: encoding input %d: %v", i, err)
+		}
+		ins[i] = in
+	}
+	return datastore.RunInTransaction(c, func(tc context.Context) error {
+		key, err := datastore.Put(tc, datastore.NewIncompleteKey(tc, FanInKind, nil), &fanIn{
+			Total:     len(ins),
+			Remaining: len(ins),
+			Next:      keys(then),
+			Created:   time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		tasks := make([]*taskqueue.Task, len(ins))
+		for i, in := range ins {
+			if tasks[i], err = runJob.Task(&job{Step: branch.key, Input: in, FanIn: key, Branch: i}); err != nil {
+				return err
+			}
+		}
+		return taskqueue.AddMultiSpill(tc, tasks, "")
+	}, txOptions)
+}
+
+func run(c context.Context, j *job) error {
+	s := steps[j.Step]
+	if s == nil {
+		return delay.Permanent(fmt.Errorf("workflow: no step with key %q found", j.Step))
+	}
+	input := j.Input
+	if j.Gather {
+		var err error
+		switch input, err = gather(c, j.FanIn); err {
+		case nil:
+		case datastore.ErrNoSuchEntity:
+			// An earlier execution of this task completed and cleaned
+			// up the fan-in.
+			return nil
+		default:
+			return err
+		}
+	}
+	in := reflect.New(s.in)
+	if err := json.Unmarshal(input, in.Interface()); err != nil {
+		return delay.Permanent(fmt.Errorf("workflow: decoding input of step %q: %v", j.Step, err))
+	}
+	out := s.fv.Call([]reflect.Value{reflect.ValueOf(c), in.Elem()})
+	if err, _ := out[1].Interface().(error); err != nil {
+		return err
+	}
+	output, err := json.Marshal(out[0].Interface())
+	if err != nil {
+		return delay.Permanent(fmt.Errorf("workflow: encoding result of step %q: %v", j.Step, err))
+	}
+
+	if j.FanIn != nil && !j.Gather {
+		return complete(c, j, output)
+	}
+	if len(j.Next) > 0 {
+		if err := next(c, &job{Step: j.Next[0], Input: output, Next: j.Next[1:]}); err != nil {
+			return err
+		}
+	}
+	if j.Gather {
+		cleanUp(c, j.FanIn)
+	}
+	return nil
+}
+
+// next adds the task of the next job. If the current task has a name, the
+// next task's name is derived from it, so that a retried step does not
+// start the rest of the workflow twice.
+func next(c context.Context, j *job) error {
+	args := []interface{}{j}
+	if h, err := delay.RequestHeaders(c); err == nil && h.TaskName != "" {
+		sum := sha1.Sum([]byte(h.QueueName + "/" + h.TaskName))
+		args = append(args, delay.WithName("workflow-"+hex.EncodeToString(sum[:])))
+	}
+	err := runJob.Call(c, args...)
+	if err == taskqueue.ErrTaskAlreadyAdded {
+		return nil
+	}
+	return err
+}
+
+// complete records the result of a branch of a fan-out, and starts the steps
+// after the fan-in if it was the last branch to complete.
+func complete(c context.Context, j *job, output []byte) error {
+	rk := datastore.NewKey(c, ResultKind, "", int64(j.Branch+1), j.FanIn)
+	return datastore.RunInTransaction(c, func(tc context.Context) error {
+		var r result
+		switch err := datastore.Get(tc, rk, &r); err {
+		case nil:
+			// The branch was retried after recording its result.
+			return nil
+		case datastore.ErrNoSuchEntity:
+		default:
+			return err
+		}
+		var f fanIn
+		switch err := datastore.Get(tc, j.FanIn, &f); err {
+		case nil:
+		case datastore.ErrNoSuchEntity:
+			// The branch was retried after the fan-in completed and
+			// was cleaned up.
+			return nil
+		default:
+			return err
+		}
+		f.Remaining--
+		if _, err := datastore.PutMulti(tc, []*datastore.Key{rk, j.FanIn}, []interface{}{&result{output}, &f}); err != nil {
+			return err
+		}
+		if f.Remaining > 0 {
+			return nil
+		}
+		// Transactional tasks cannot be named, but the transaction
+		// ensures that the task is added exactly once.
+		return runJob.Call(tc, &job{Step: f.Next[0], Next: f.Next[1:], FanIn: j.FanIn, Gather: true})
+	}, txOptions)
+}
+
+// gather returns the results of the branches of a fan-out, as a JSON array.
+func gather(c context.Context, key *datastore.Key) ([]byte, error) {
+	var f fanIn
+	if err := datastore.Get(c, key, &f); err != nil {
+		return nil, err
+	}
+	rks := make([]*datastore.Key, f.Total)
+	for i := range rks {
+		rks[i] = datastore.NewKey(c, ResultKind, "", int64(i+1), key)
+	}
+	rs := make([]result, f.Total)
+	if err := datastore.GetMulti(c, rks, rs); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, r := range rs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(r.Output)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// cleanUp deletes the entities of a completed fan-out. Failures are
+// harmless, so they are ignored.
+func cleanUp(c context.Context, key *datastore.Key) {
+	var f fanIn
+	if err := datastore.Get(c, key, &f); err != nil {
+		return
+	}
+	ks := []*datastore.Key{key}
+	for i := 0; i < f.Total; i++ {
+		ks = append(ks, datastore.NewKey(c, ResultKind, "", int64(i+1), key))
+	}
+	datastore.DeleteMulti(c, ks)
+}
@@ -0,0 +1,307 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/datastore"
+	"google.golang.org/appengine/v2/delay"
+	"google.golang.org/appengine/v2/internal"
+	dspb "google.golang.org/appengine/v2/internal/datastore"
+	tqpb "google.golang.org/appengine/v2/internal/taskqueue"
+	"google.golang.org/appengine/v2/taskqueue"
+)
+
+// fakeService is an in-memory datastore and push queue, just sufficient for
+// workflows.
+type fakeService struct {
+	entities map[string]*dspb.EntityProto
+	nextID   int64
+	tasks    []*tqpb.TaskQueueAddRequest
+	names    map[string]bool
+	executed int
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{
+		entities: make(map[string]*dspb.EntityProto),
+		nextID:   1,
+		names:    make(map[string]bool),
+	}
+}
+
+func (f *fakeService) context() context.Context {
+	c := internal.WithCallOverride(context.Background(), f.call)
+	c = internal.WithAppIDOverride(c, "dev~fake-app")
+	return internal.WithLogOverride(c, func(level int64, format string, args ...interface{}) {})
+}
+
+func refKey(r *dspb.Reference) string {
+	return proto.CompactTextString(r.Path)
+}
+
+func (f *fakeService) call(ctx context.Context, service, method string, in, out proto.Message) error {
+	switch service + "." + method {
+	case "datastore_v3.BeginTransaction":
+		out.(*dspb.Transaction).Handle = proto.Uint64(1)
+		out.(*dspb.Transaction).App = proto.String("dev~fake-app")
+	case "datastore_v3.Commit", "datastore_v3.Rollback":
+	case "datastore_v3.Get":
+		res := out.(*dspb.GetResponse)
+		for _, k := range in.(*dspb.GetRequest).Key {
+			res.Entity = append(res.Entity, &dspb.GetResponse_Entity{Entity: f.entities[refKey(k)], Key: k})
+		}
+	case "datastore_v3.Put":
+		res := out.(*dspb.PutResponse)
+		for _, e := range in.(*dspb.PutRequest).Entity {
+			e = proto.Clone(e).(*dspb.EntityProto)
+			els := e.Key.Path.Element
+			if last := els[len(els)-1]; last.Id == nil && last.Name == nil {
+				last.Id = proto.Int64(f.nextID)
+				f.nextID++
+			}
+			f.entities[refKey(e.Key)] = e
+			res.Key = append(res.Key, e.Key)
+		}
+	case "datastore_v3.Delete":
+		for _, k := range in.(*dspb.DeleteRequest).Key {
+			delete(f.entities, refKey(k))
+		}
+		out.(*dspb.DeleteResponse).Reset()
+	case "taskqueue.Add":
+		return f.add(in.(*tqpb.TaskQueueAddRequest))
+	case "taskqueue.BulkAdd":
+		res := out.(*tqpb.TaskQueueBulkAddResponse)
+		for _, req := range in.(*tqpb.TaskQueueBulkAddRequest).AddRequest {
+			code := tqpb.TaskQueueServiceError_OK
+			if err := f.add(req); err != nil {
+				code = tqpb.TaskQueueServiceError_ErrorCode(err.(*internal.APIError).Code)
+			}
+			res.Taskresult = append(res.Taskresult, &tqpb.TaskQueueBulkAddResponse_TaskResult{Result: code.Enum()})
+		}
+	default:
+		return fmt.Errorf("unexpected call %s.%s", service, method)
+	}
+	return nil
+}
+
+func (f *fakeService) add(req *tqpb.TaskQueueAddRequest) error {
+	if len(req.TaskName) == 0 {
+		req.TaskName = []byte(fmt.Sprintf("task%d", len(f.tasks)))
+	} else if f.names[string(req.TaskName)] {
+		return &internal.APIError{Service: "taskqueue", Code: int32(tqpb.TaskQueueServiceError_TASK_ALREADY_EXISTS)}
+	}
+	f.names[string(req.TaskName)] = true
+	f.tasks = append(f.tasks, req)
+	return nil
+}
+
+// run executes tasks until the queue is empty, retrying failed tasks up to
+// three times.
+func (f *fakeService) run(t *testing.T) {
+	h := http.NewServeMux()
+	h.Handle("/_ah/queue/go/delay", delay.NewHandler())
+	h.Handle("/_ah/queue/go/taskqueue-spill", taskqueue.SpillHandler())
+	failures := make(map[string]int)
+	for len(f.tasks) > 0 {
+		task := f.tasks[0]
+		f.tasks = f.tasks[1:]
+		req := httptest.NewRequest("POST", string(task.Url), bytes.NewReader(task.Body))
+		for _, hd := range task.Header {
+			req.Header.Add(string(hd.Key), string(hd.Value))
+		}
+		req.Header.Set("X-AppEngine-QueueName", string(task.QueueName))
+		req.Header.Set("X-AppEngine-TaskName", string(task.TaskName))
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req.WithContext(f.context()))
+		f.executed++
+		if rw.Code != http.StatusOK {
+			if failures[string(task.TaskName)]++; failures[string(task.TaskName)] < 3 {
+				f.tasks = append(f.tasks, task)
+			}
+		}
+	}
+}
+
+type page struct {
+	URL   string
+	Links int
+}
+
+var (
+	trace []string
+
+	fetchFailures int
+	fetch         = MustRegister("fetch", func(c context.Context, url string) (page, error) {
+		if url == "flaky" && fetchFailures > 0 {
+			fetchFailures--
+			return page{}, errors.New("timeout")
+		}
+		trace = append(trace, "fetch "+url)
+		return page{URL: url, Links: len(url)}, nil
+	})
+	index = MustRegister("index", func(c context.Context, p page) (int, error) {
+		trace = append(trace, fmt.Sprintf("index %s %d", p.URL, p.Links))
+		return p.Links * 10, nil
+	})
+	report = MustRegister("report", func(c context.Context, ps []page) (string, error) {
+		var urls []string
+		for _, p := range ps {
+			urls = append(urls, p.URL)
+		}
+		trace = append(trace, "report "+strings.Join(urls, ","))
+		return "ok", nil
+	})
+	done = MustRegister("done", func(c context.Context, s string) (struct{}, error) {
+		trace = append(trace, "done "+s)
+		return struct{}{}, nil
+	})
+)
+
+func TestChain(t *testing.T) {
+	f := newFakeService()
+	trace = nil
+	if err := Chain(f.context(), "a.com", fetch, index); err != nil {
+		t.Fatalf("Chain: %v", err)
+	}
+	f.run(t)
+	want := []string{"fetch a.com", "index a.com 5"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	f := newFakeService()
+	trace, fetchFailures = nil, 1
+	if err := FanOut(f.context(), []string{"a", "flaky", "c"}, fetch, report, done); err != nil {
+		t.Fatalf("FanOut: %v", err)
+	}
+	f.run(t)
+	want := []string{"fetch a", "fetch c", "fetch flaky", "report a,flaky,c", "done ok"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+	if len(f.entities) != 0 {
+		t.Errorf("%d entities left after the workflow", len(f.entities))
+	}
+}
+
+func TestFanOutSpill(t *testing.T) {
+	// The tasks of more branches than fit in a transaction are added by a
+	// spill task.
+	f := newFakeService()
+	trace = nil
+	urls := []string{"a", "b", "c", "d", "e", "f", "g"}
+	if err := FanOut(f.context(), urls, fetch, report); err != nil {
+		t.Fatalf("FanOut: %v", err)
+	}
+	if len(f.tasks) != 1 {
+		t.Fatalf("FanOut added %d tasks, want one spill task", len(f.tasks))
+	}
+	f.run(t)
+	if n := len(trace); n != len(urls)+1 || trace[n-1] != "report a,b,c,d,e,f,g" {
+		t.Errorf("trace = %q, want every fetch then the report", trace)
+	}
+}
+
+func TestFanOutRetriedBranch(t *testing.T) {
+	// A branch that runs twice, because its task is retried after it
+	// recorded its result, is counted once.
+	f := newFakeService()
+	trace = nil
+	if err := FanOut(f.context(), []string{"a", "b"}, fetch, report); err != nil {
+		t.Fatalf("FanOut: %v", err)
+	}
+	f.tasks = append(f.tasks, proto.Clone(f.tasks[0]).(*tqpb.TaskQueueAddRequest))
+	f.run(t)
+	want := []string{"fetch a", "fetch b", "fetch a", "report a,b"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+}
+
+func TestFanInRetriedAfterCleanUp(t *testing.T) {
+	// The gather job, or a branch, may run again after the gather job
+	// completed and deleted the fan-in's entities.
+	f := newFakeService()
+	trace = nil
+	c := f.context()
+	key, err := datastore.Put(c, datastore.NewIncompleteKey(c, FanInKind, nil), &fanIn{Total: 1, Next: []string{"report"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := datastore.Put(c, datastore.NewKey(c, ResultKind, "", 1, key), &result{[]byte(`{"URL":"a"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	gatherJob := &job{Step: "report", FanIn: key, Gather: true}
+	for i := 0; i < 2; i++ {
+		if err := run(c, gatherJob); err != nil {
+			t.Errorf("gather job run %d: %v", i+1, err)
+		}
+	}
+	if err := run(c, &job{Step: "fetch", Input: []byte(`"a"`), FanIn: key}); err != nil {
+		t.Errorf("branch run after the fan-in completed: %v", err)
+	}
+	if want := []string{"report a", "fetch a"}; !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+	if len(f.entities) != 0 || len(f.tasks) != 0 {
+		t.Errorf("%d entities and %d tasks left, want none", len(f.entities), len(f.tasks))
+	}
+}
+
+func TestFanOutEmpty(t *testing.T) {
+	f := newFakeService()
+	trace = nil
+	if err := FanOut(f.context(), []string{}, fetch, report); err != nil {
+		t.Fatalf("FanOut: %v", err)
+	}
+	f.run(t)
+	if want := []string{"report "}; !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	f := newFakeService()
+	if err := Chain(f.context(), "x"); err == nil {
+		t.Error("Chain without steps succeeded")
+	}
+	if err := FanOut(f.context(), "x", fetch, report); err == nil {
+		t.Error("FanOut of a non-slice succeeded")
+	}
+	if err := FanOut(f.context(), []string{"x"}, fetch); err == nil {
+		t.Error("FanOut without steps after it succeeded")
+	}
+
+	// A step whose input cannot be decoded stops the workflow.
+	trace = nil
+	if err := Chain(f.context(), 42, fetch, index); err != nil {
+		t.Fatalf("Chain: %v", err)
+	}
+	f.run(t)
+	if len(trace) != 0 || f.executed != 1 {
+		t.Errorf("bad input ran steps %q in %d executions, want none in 1", trace, f.executed)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustRegister of a bad function did not panic")
+		}
+	}()
+	MustRegister("bad", func(c context.Context, s string) error { return nil })
+}
@@ -7,7 +7,6 @@ package image // import "google.golang.org/appengine/v2/image"
 
 import (
 	"context"
-	"fmt"
 	"net/url"
 
 	"google.golang.org/appengine/v2"
@@ -15,6 +14,8 @@ import (
 	pb "google.golang.org/appengine/v2/internal/image"
 )
 
+// ServingURLOptions are the options for ServingURL. Use BuildServingURL for
+// the full set of serving URL modifiers.
 type ServingURLOptions struct {
 	Secure bool // whether the URL should use HTTPS
 
@@ -39,17 +40,14 @@ func ServingURL(c context.Context, key appengine.BlobKey, opts *ServingURLOption
 		return nil, err
 	}
 
-	// The URL may have suffixes added to dynamically resize or crop:
-	// - adding "=s32" will serve the image resized to 32 pixels, preserving the aspect ratio.
-	// - adding "=s32-c" is the same as "=s32" except it will be cropped.
-	u := *res.Url
+	// The URL may have modifiers added to dynamically resize or crop; see
+	// BuildServingURL for the full set.
+	var uo URLOptions
 	if opts != nil && opts.Size > 0 {
-		u += fmt.Sprintf("=s%d", opts.Size)
-		if opts.Crop {
-			u += "-c"
-		}
+		uo.Size = opts.Size
+		uo.Crop = opts.Crop
 	}
-	return url.Parse(u)
+	return BuildServingURL(*res.Url, &uo)
 }
 
 // DeleteServingURL deletes the serving URL for an image.
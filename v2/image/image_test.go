@@ -0,0 +1,52 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/appengine/v2/image/imagetest"
+	"google.golang.org/appengine/v2/internal"
+)
+
+func TestServingURL(t *testing.T) {
+	s := imagetest.NewService()
+	s.AddBlob("blob", []byte("data"))
+	c := s.NewContext(internal.ContextForTesting(&http.Request{}))
+
+	testCases := []struct {
+		opts *ServingURLOptions
+		want string
+	}{
+		{nil, "http://localhost:8080/_ah/img/blob"},
+		{&ServingURLOptions{Secure: true}, "https://localhost:8080/_ah/img/blob"},
+		{&ServingURLOptions{Size: 32}, "http://localhost:8080/_ah/img/blob=s32"},
+		{&ServingURLOptions{Size: 32, Crop: true}, "http://localhost:8080/_ah/img/blob=s32-c"},
+	}
+	for _, tc := range testCases {
+		u, err := ServingURL(c, "blob", tc.opts)
+		if err != nil {
+			t.Errorf("ServingURL(%+v): %v", tc.opts, err)
+			continue
+		}
+		if u.String() != tc.want {
+			t.Errorf("ServingURL(%+v) = %q, want %q", tc.opts, u, tc.want)
+		}
+	}
+	if !s.HasServingURL("blob") {
+		t.Error("HasServingURL = false after ServingURL")
+	}
+
+	if err := DeleteServingURL(c, "blob"); err != nil {
+		t.Fatalf("DeleteServingURL: %v", err)
+	}
+	if s.HasServingURL("blob") {
+		t.Error("HasServingURL = true after DeleteServingURL")
+	}
+	if _, err := ServingURL(c, "missing", nil); err == nil {
+		t.Error("ServingURL of unknown blob succeeded")
+	}
+}
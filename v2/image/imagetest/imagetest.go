@@ -0,0 +1,382 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+/*
+Package imagetest provides an in-process implementation of the images
+service, for testing and developing code that uses the image package
+without dev_appserver.
+
+The implementation uses the standard library's image packages. It supports
+all the operations of image.Transform except ImFeelingLucky and
+CorrectOrientation, which leave the image unchanged, as well as
+image.Composite, image.GetHistogram, image.ServingURL and
+image.DeleteServingURL. It reads and writes PNG and JPEG images; WEBP is
+not supported. Resizing uses nearest-neighbor sampling, so the results are
+not pixel-for-pixel identical to those of the production service.
+
+Example:
+
+	func TestThumbnail(t *testing.T) {
+		s := imagetest.NewService()
+		ctx := s.NewContext(context.Background())
+		thumb, err := image.NewTransform().Resize(32, 32).Apply(ctx, &image.Image{Data: photo})
+		...
+	}
+*/
+package imagetest // import "google.golang.org/appengine/v2/image/imagetest"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/image"
+)
+
+// DefaultBaseURL is the default value of Service.BaseURL.
+const DefaultBaseURL = "http://localhost:8080/_ah/img/"
+
+// Service is an in-process implementation of the images service.
+type Service struct {
+	// BaseURL is the prefix of the serving URLs returned for blobs. The
+	// serving URL of a blob is BaseURL followed by the blob key, so the
+	// URLs are deterministic. For secure URLs, the scheme is replaced by
+	// https.
+	BaseURL string
+
+	mu    sync.Mutex
+	blobs map[appengine.BlobKey][]byte
+	urls  map[appengine.BlobKey]bool
+}
+
+// NewService returns a Service with no blobs.
+func NewService() *Service {
+	return &Service{
+		BaseURL: DefaultBaseURL,
+		blobs:   make(map[appengine.BlobKey][]byte),
+		urls:    make(map[appengine.BlobKey]bool),
+	}
+}
+
+// AddBlob makes data available to requests that refer to the blob key.
+func (s *Service) AddBlob(key appengine.BlobKey, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = data
+}
+
+// HasServingURL reports whether a serving URL has been created, and not
+// deleted, for the blob key.
+func (s *Service) HasServingURL(key appengine.BlobKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.urls[key]
+}
+
+// NewContext returns a context derived from parent whose images service calls
+// are handled by s. Calls to other services are passed on to parent.
+func (s *Service) NewContext(parent context.Context) context.Context {
+	return internal.WithCallOverride(parent, s.call)
+}
+
+func (s *Service) call(ctx context.Context, service, method string, in, out proto.Message) error {
+	if service != "images" {
+		return internal.Call(ctx, service, method, in, out)
+	}
+	switch method {
+	case "Transform":
+		return s.transform(in.(*pb.ImagesTransformRequest), out.(*pb.ImagesTransformResponse))
+	case "Composite":
+		return s.composite(in.(*pb.ImagesCompositeRequest), out.(*pb.ImagesCompositeResponse))
+	case "Histogram":
+		return s.histogram(in.(*pb.ImagesHistogramRequest), out.(*pb.ImagesHistogramResponse))
+	case "GetUrlBase":
+		return s.getURLBase(in.(*pb.ImagesGetUrlBaseRequest), out.(*pb.ImagesGetUrlBaseResponse))
+	case "DeleteUrlBase":
+		return s.deleteURLBase(in.(*pb.ImagesDeleteUrlBaseRequest))
+	}
+	return fmt.Errorf("Unknown API call /%s.%s", service, method)
+}
+
+// serviceError returns an images service error with the given code.
+func serviceError(code pb.ImagesServiceError_ErrorCode, format string, args ...interface{}) error {
+	return &internal.APIError{
+		Service: "images",
+		Code:    int32(code),
+		Detail:  fmt.Sprintf(format, args...),
+	}
+}
+
+// decode decodes the image in d, reading it from a blob if necessary.
+func (s *Service) decode(d *pb.ImageData) (image.Image, error) {
+	data := d.GetContent()
+	if d.BlobKey != nil {
+		s.mu.Lock()
+		b, ok := s.blobs[appengine.BlobKey(d.GetBlobKey())]
+		s.mu.Unlock()
+		if !ok {
+			return nil, serviceError(pb.ImagesServiceError_INVALID_BLOB_KEY, "unknown blob key %q", d.GetBlobKey())
+		}
+		data = b
+	}
+	m, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, serviceError(pb.ImagesServiceError_NOT_IMAGE, "%v", err)
+	}
+	return m, nil
+}
+
+// encode encodes m according to the output settings o.
+func encode(m image.Image, o *pb.OutputSettings) (*pb.ImageData, error) {
+	var buf bytes.Buffer
+	var err error
+	switch o.GetMimeType() {
+	case pb.OutputSettings_PNG:
+		err = png.Encode(&buf, m)
+	case pb.OutputSettings_JPEG:
+		opts := &jpeg.Options{Quality: jpeg.DefaultQuality}
+		if o.Quality != nil {
+			opts.Quality = int(o.GetQuality())
+		}
+		err = jpeg.Encode(&buf, m, opts)
+	default:
+		return nil, serviceError(pb.ImagesServiceError_BAD_TRANSFORM_DATA, "unsupported output format %v", o.GetMimeType())
+	}
+	if err != nil {
+		return nil, serviceError(pb.ImagesServiceError_UNSPECIFIED_ERROR, "%v", err)
+	}
+	b := m.Bounds()
+	return &pb.ImageData{
+		Content: buf.Bytes(),
+		Width:   proto.Int32(int32(b.Dx())),
+		Height:  proto.Int32(int32(b.Dy())),
+	}, nil
+}
+
+func (s *Service) transform(req *pb.ImagesTransformRequest, res *pb.ImagesTransformResponse) error {
+	m, err := s.decode(req.Image)
+	if err != nil {
+		return err
+	}
+	for _, t := range req.Transform {
+		if m, err = apply(m, t); err != nil {
+			return err
+		}
+	}
+	res.Image, err = encode(m, req.Output)
+	return err
+}
+
+// apply applies the operations in t to m, in the order used by the
+// production service.
+func apply(m image.Image, t *pb.Transform) (image.Image, error) {
+	if t.Width != nil || t.Height != nil {
+		var err error
+		if m, err = resize(m, t); err != nil {
+			return nil, err
+		}
+	}
+	if t.GetRotate() != 0 {
+		if t.GetRotate()%90 != 0 {
+			return nil, serviceError(pb.ImagesServiceError_BAD_TRANSFORM_DATA, "invalid rotation %d", t.GetRotate())
+		}
+		for i := 0; i < int(t.GetRotate()/90)%4; i++ {
+			m = remap(m, m.Bounds().Dy(), m.Bounds().Dx(), func(x, y int, b image.Rectangle) (int, int) {
+				// Rotating clockwise, destination (x, y) comes from
+				// source (y, h-1-x).
+				return y, b.Dy() - 1 - x
+			})
+		}
+	}
+	if t.GetHorizontalFlip() {
+		m = remap(m, m.Bounds().Dx(), m.Bounds().Dy(), func(x, y int, b image.Rectangle) (int, int) {
+			return b.Dx() - 1 - x, y
+		})
+	}
+	if t.GetVerticalFlip() {
+		m = remap(m, m.Bounds().Dx(), m.Bounds().Dy(), func(x, y int, b image.Rectangle) (int, int) {
+			return x, b.Dy() - 1 - y
+		})
+	}
+	if t.CropLeftX != nil || t.CropTopY != nil || t.CropRightX != nil || t.CropBottomY != nil {
+		l, tp, r, bt := t.GetCropLeftX(), t.GetCropTopY(), t.GetCropRightX(), t.GetCropBottomY()
+		if l < 0 || tp < 0 || r > 1 || bt > 1 || l >= r || tp >= bt {
+			return nil, serviceError(pb.ImagesServiceError_BAD_TRANSFORM_DATA, "invalid crop box")
+		}
+		b := m.Bounds()
+		m = crop(m, image.Rect(
+			int(math.Round(float64(l)*float64(b.Dx()))),
+			int(math.Round(float64(tp)*float64(b.Dy()))),
+			int(math.Round(float64(r)*float64(b.Dx()))),
+			int(math.Round(float64(bt)*float64(b.Dy()))),
+		))
+	}
+	return m, nil
+}
+
+// resize implements the resize operation of t.
+func resize(m image.Image, t *pb.Transform) (image.Image, error) {
+	w, h := int(t.GetWidth()), int(t.GetHeight())
+	if w < 0 || h < 0 || w+h == 0 {
+		return nil, serviceError(pb.ImagesServiceError_BAD_TRANSFORM_DATA, "invalid resize dimensions %dx%d", w, h)
+	}
+	b := m.Bounds()
+	sw, sh := float64(b.Dx()), float64(b.Dy())
+	switch {
+	case t.GetAllowStretch():
+		return scale(m, w, h), nil
+	case t.GetCropToFit():
+		// Scale to cover w by h, then crop the excess.
+		f := math.Max(float64(w)/sw, float64(h)/sh)
+		sm := scale(m, int(math.Round(sw*f)), int(math.Round(sh*f)))
+		sb := sm.Bounds()
+		x := int(math.Round(float64(sb.Dx()-w) * float64(t.GetCropOffsetX())))
+		y := int(math.Round(float64(sb.Dy()-h) * float64(t.GetCropOffsetY())))
+		return crop(sm, image.Rect(x, y, x+w, y+h)), nil
+	}
+	f := math.Inf(1)
+	if w > 0 {
+		f = float64(w) / sw
+	}
+	if h > 0 {
+		f = math.Min(f, float64(h)/sh)
+	}
+	return scale(m, int(math.Max(1, math.Round(sw*f))), int(math.Max(1, math.Round(sh*f)))), nil
+}
+
+// remap returns a w by h image whose pixel (x, y) is the pixel of m given by
+// f, in coordinates relative to m's bounds b.
+func remap(m image.Image, w, h int, f func(x, y int, b image.Rectangle) (int, int)) image.Image {
+	b := m.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := f(x, y, b)
+			dst.Set(x, y, m.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// scale resizes m to w by h pixels with nearest-neighbor sampling.
+func scale(m image.Image, w, h int) image.Image {
+	return remap(m, w, h, func(x, y int, b image.Rectangle) (int, int) {
+		return x * b.Dx() / w, y * b.Dy() / h
+	})
+}
+
+// crop returns the part of m within r, in coordinates relative to m's
+// bounds.
+func crop(m image.Image, r image.Rectangle) image.Image {
+	return remap(m, r.Dx(), r.Dy(), func(x, y int, b image.Rectangle) (int, int) {
+		return r.Min.X + x, r.Min.Y + y
+	})
+}
+
+func (s *Service) composite(req *pb.ImagesCompositeRequest, res *pb.ImagesCompositeResponse) error {
+	cv := req.Canvas
+	w, h := int(cv.GetWidth()), int(cv.GetHeight())
+	if w <= 0 || h <= 0 {
+		return serviceError(pb.ImagesServiceError_BAD_TRANSFORM_DATA, "invalid canvas dimensions %dx%d", w, h)
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	bg := uint32(cv.GetColor())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.NRGBA{
+		R: uint8(bg >> 16), G: uint8(bg >> 8), B: uint8(bg), A: uint8(bg >> 24),
+	}), image.Point{}, draw.Src)
+
+	for _, o := range req.Options {
+		i := int(o.GetSourceIndex())
+		if i < 0 || i >= len(req.Image) {
+			return serviceError(pb.ImagesServiceError_BAD_TRANSFORM_DATA, "invalid source index %d", i)
+		}
+		m, err := s.decode(req.Image[i])
+		if err != nil {
+			return err
+		}
+		mb := m.Bounds()
+		x, y := anchorOffset(o.GetAnchor(), w-mb.Dx(), h-mb.Dy())
+		r := image.Rect(0, 0, mb.Dx(), mb.Dy()).Add(image.Pt(x+int(o.GetXOffset()), y+int(o.GetYOffset())))
+		mask := image.NewUniform(color.Alpha{A: uint8(math.Round(255 * float64(o.GetOpacity())))})
+		draw.DrawMask(dst, r, m, mb.Min, mask, image.Point{}, draw.Over)
+	}
+	var err error
+	res.Image, err = encode(dst, cv.Output)
+	return err
+}
+
+// anchorOffset returns the position of a layer with the given anchor, where
+// dx and dy are the differences between the canvas and layer sizes.
+func anchorOffset(a pb.CompositeImageOptions_ANCHOR, dx, dy int) (x, y int) {
+	switch a {
+	case pb.CompositeImageOptions_TOP, pb.CompositeImageOptions_CENTER, pb.CompositeImageOptions_BOTTOM:
+		x = dx / 2
+	case pb.CompositeImageOptions_TOP_RIGHT, pb.CompositeImageOptions_RIGHT, pb.CompositeImageOptions_BOTTOM_RIGHT:
+		x = dx
+	}
+	switch a {
+	case pb.CompositeImageOptions_LEFT, pb.CompositeImageOptions_CENTER, pb.CompositeImageOptions_RIGHT:
+		y = dy / 2
+	case pb.CompositeImageOptions_BOTTOM_LEFT, pb.CompositeImageOptions_BOTTOM, pb.CompositeImageOptions_BOTTOM_RIGHT:
+		y = dy
+	}
+	return x, y
+}
+
+func (s *Service) histogram(req *pb.ImagesHistogramRequest, res *pb.ImagesHistogramResponse) error {
+	m, err := s.decode(req.Image)
+	if err != nil {
+		return err
+	}
+	h := &pb.ImagesHistogram{
+		Red:   make([]int32, 256),
+		Green: make([]int32, 256),
+		Blue:  make([]int32, 256),
+	}
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			h.Red[c.R]++
+			h.Green[c.G]++
+			h.Blue[c.B]++
+		}
+	}
+	res.Histogram = h
+	return nil
+}
+
+func (s *Service) getURLBase(req *pb.ImagesGetUrlBaseRequest, res *pb.ImagesGetUrlBaseResponse) error {
+	key := appengine.BlobKey(req.GetBlobKey())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[key]; !ok {
+		return serviceError(pb.ImagesServiceError_INVALID_BLOB_KEY, "unknown blob key %q", key)
+	}
+	s.urls[key] = true
+	u := s.BaseURL + string(key)
+	if req.GetCreateSecureUrl() && len(u) > len("http:") && u[:len("http:")] == "http:" {
+		u = "https:" + u[len("http:"):]
+	}
+	res.Url = proto.String(u)
+	return nil
+}
+
+func (s *Service) deleteURLBase(req *pb.ImagesDeleteUrlBaseRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.urls, appengine.BlobKey(req.GetBlobKey()))
+	return nil
+}
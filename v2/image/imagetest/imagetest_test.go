@@ -0,0 +1,163 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package imagetest_test
+
+import (
+	"bytes"
+	stdimage "image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"google.golang.org/appengine/v2/image"
+	"google.golang.org/appengine/v2/image/imagetest"
+	"google.golang.org/appengine/v2/internal"
+)
+
+// testImage returns a w by h PNG image whose left half is red and right half
+// is blue.
+func testImage(t *testing.T, w, h int) []byte {
+	m := stdimage.NewNRGBA(stdimage.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			m.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, b []byte) stdimage.Image {
+	m, _, err := stdimage.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+	return m
+}
+
+func rgba(c color.Color) color.NRGBA {
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
+}
+
+func TestTransform(t *testing.T) {
+	s := imagetest.NewService()
+	c := s.NewContext(internal.ContextForTesting(&http.Request{}))
+	img := &image.Image{Data: testImage(t, 40, 20)}
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+
+	testCases := []struct {
+		desc       string
+		tr         *image.Transform
+		w, h       int
+		topLeft    color.NRGBA
+		bottomLeft color.NRGBA
+	}{
+		{"resize", image.NewTransform().Resize(20, 20), 20, 10, red, red},
+		{"resize to fill", image.NewTransform().ResizeToFill(10, 10, 1, 0.5), 10, 10, blue, blue},
+		{"stretch", image.NewTransform().Stretch(10, 30), 10, 30, red, red},
+		{"crop", image.NewTransform().Crop(0.5, 0, 1, 0.5), 20, 10, blue, blue},
+		{"rotate", image.NewTransform().Rotate(90), 20, 40, red, blue},
+		{"flip", image.NewTransform().HorizontalFlip(), 40, 20, blue, blue},
+		{"rotate and flip", image.NewTransform().Rotate(270).VerticalFlip(), 20, 40, red, blue},
+	}
+	for _, tc := range testCases {
+		b, err := tc.tr.Apply(c, img)
+		if err != nil {
+			t.Errorf("%s: Apply: %v", tc.desc, err)
+			continue
+		}
+		m := decode(t, b)
+		r := m.Bounds()
+		if r.Dx() != tc.w || r.Dy() != tc.h {
+			t.Errorf("%s: size = %dx%d, want %dx%d", tc.desc, r.Dx(), r.Dy(), tc.w, tc.h)
+			continue
+		}
+		if got := rgba(m.At(r.Min.X, r.Min.Y)); got != tc.topLeft {
+			t.Errorf("%s: top left = %v, want %v", tc.desc, got, tc.topLeft)
+		}
+		if got := rgba(m.At(r.Min.X, r.Max.Y-1)); got != tc.bottomLeft {
+			t.Errorf("%s: bottom left = %v, want %v", tc.desc, got, tc.bottomLeft)
+		}
+	}
+}
+
+func TestTransformJPEG(t *testing.T) {
+	s := imagetest.NewService()
+	c := s.NewContext(internal.ContextForTesting(&http.Request{}))
+	b, err := image.NewTransform().Resize(10, 10).Encoding(image.JPEG, 90).Apply(c, &image.Image{Data: testImage(t, 20, 20)})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if _, format, err := stdimage.Decode(bytes.NewReader(b)); err != nil || format != "jpeg" {
+		t.Errorf("decoding result: format %q, err %v; want jpeg", format, err)
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	s := imagetest.NewService()
+	c := s.NewContext(internal.ContextForTesting(&http.Request{}))
+	tr := image.NewTransform().Resize(10, 10)
+	if _, err := tr.Apply(c, &image.Image{Data: []byte("not an image")}); err == nil {
+		t.Error("Apply of invalid data succeeded")
+	}
+	if _, err := tr.Apply(c, &image.Image{BlobKey: "missing"}); err == nil {
+		t.Error("Apply of unknown blob succeeded")
+	}
+	if _, err := tr.Encoding(image.WEBP, 0).Apply(c, &image.Image{Data: testImage(t, 4, 4)}); err == nil {
+		t.Error("Apply with WEBP output succeeded")
+	}
+}
+
+func TestComposite(t *testing.T) {
+	s := imagetest.NewService()
+	s.AddBlob("layer", testImage(t, 4, 4))
+	c := s.NewContext(internal.ContextForTesting(&http.Request{}))
+
+	canvas := &image.Canvas{Width: 10, Height: 10, Color: color.Black, Format: image.PNG}
+	layers := []image.Layer{
+		{Image: &image.Image{BlobKey: "layer"}, Anchor: image.BottomRight},
+		{Image: &image.Image{BlobKey: "layer"}, X: 1, Y: 1, Transparent: true, Anchor: image.TopLeft},
+	}
+	b, err := image.Composite(c, canvas, layers)
+	if err != nil {
+		t.Fatalf("Composite: %v", err)
+	}
+	m := decode(t, b)
+	checks := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{0, 0, color.NRGBA{A: 255}},
+		{1, 1, color.NRGBA{A: 255}}, // Transparent layer.
+		{6, 6, color.NRGBA{R: 255, A: 255}},
+		{9, 9, color.NRGBA{B: 255, A: 255}},
+	}
+	for _, ch := range checks {
+		if got := rgba(m.At(ch.x, ch.y)); got != ch.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", ch.x, ch.y, got, ch.want)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	s := imagetest.NewService()
+	c := s.NewContext(internal.ContextForTesting(&http.Request{}))
+	h, err := image.GetHistogram(c, &image.Image{Data: testImage(t, 4, 2)})
+	if err != nil {
+		t.Fatalf("GetHistogram: %v", err)
+	}
+	if h.Red[255] != 4 || h.Red[0] != 4 || h.Blue[255] != 4 || h.Green[0] != 8 {
+		t.Errorf("histogram: red[0]=%d red[255]=%d green[0]=%d blue[255]=%d; want 4, 4, 8, 4",
+			h.Red[0], h.Red[255], h.Green[0], h.Blue[255])
+	}
+}
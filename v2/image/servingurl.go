@@ -0,0 +1,227 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URLOptions are the modifiers that may be appended to a serving URL to
+// change how the image is served. The zero value serves the image with the
+// service's defaults.
+type URLOptions struct {
+	// Size, if non-zero, scales the image so that its longest dimension is
+	// Size pixels, preserving the aspect ratio ("s").
+	Size int
+	// Original serves the image at its original size ("s0"). It overrides
+	// Size.
+	Original bool
+	// Width and Height, if non-zero, scale the image to the given width or
+	// height ("w", "h").
+	Width, Height int
+	// Crop crops the image from the center to a square instead of resizing
+	// it ("c").
+	Crop bool
+	// SmartCrop crops the image around its most interesting region instead
+	// of the center ("p").
+	SmartCrop bool
+	// Circle crops the image to a circle ("cc").
+	Circle bool
+	// Rotate rotates the image clockwise by the given number of degrees,
+	// which must be a multiple of 90 ("r").
+	Rotate int
+	// FlipHorizontal and FlipVertical flip the image ("fh", "fv").
+	FlipHorizontal, FlipVertical bool
+	// Convert serves the image encoded as Format ("rp", "rj", "rw").
+	Convert bool
+	Format  Format
+	// Quality, if non-zero, sets the quality of a JPEG or WEBP image, between
+	// 1 and 100 ("l").
+	Quality int
+}
+
+// formatOptions maps formats to their serving URL modifiers.
+var formatOptions = map[Format]string{
+	PNG:  "rp",
+	JPEG: "rj",
+	WEBP: "rw",
+}
+
+// suffix returns the modifiers for o, separated by dashes.
+func (o *URLOptions) suffix() (string, error) {
+	var mods []string
+	dim := func(name string, v int) error {
+		if v < 0 {
+			return fmt.Errorf("image: negative %s %d", name, v)
+		}
+		if v > 0 {
+			mods = append(mods, name[:1]+strconv.Itoa(v))
+		}
+		return nil
+	}
+	if o.Original {
+		mods = append(mods, "s0")
+	} else if err := dim("size", o.Size); err != nil {
+		return "", err
+	}
+	if err := dim("width", o.Width); err != nil {
+		return "", err
+	}
+	if err := dim("height", o.Height); err != nil {
+		return "", err
+	}
+	if o.Crop {
+		mods = append(mods, "c")
+	}
+	if o.SmartCrop {
+		mods = append(mods, "p")
+	}
+	if o.Circle {
+		mods = append(mods, "cc")
+	}
+	if o.Rotate%90 != 0 {
+		return "", fmt.Errorf("image: rotation %d is not a multiple of 90 degrees", o.Rotate)
+	}
+	if r := (o.Rotate%360 + 360) % 360; r != 0 {
+		mods = append(mods, "r"+strconv.Itoa(r))
+	}
+	if o.FlipHorizontal {
+		mods = append(mods, "fh")
+	}
+	if o.FlipVertical {
+		mods = append(mods, "fv")
+	}
+	if o.Convert {
+		m, ok := formatOptions[o.Format]
+		if !ok {
+			return "", fmt.Errorf("image: unknown format %d", o.Format)
+		}
+		mods = append(mods, m)
+	}
+	if o.Quality != 0 {
+		if o.Quality < 1 || o.Quality > 100 {
+			return "", fmt.Errorf("image: quality %d is not between 1 and 100", o.Quality)
+		}
+		mods = append(mods, "l"+strconv.Itoa(o.Quality))
+	}
+	return strings.Join(mods, "-"), nil
+}
+
+// BuildServingURL returns the serving URL base, as returned by ServingURL,
+// with the modifiers for opts appended. The base is used as is, so it must
+// not have modifiers already; ParseServingURL removes them. If opts is nil or
+// has no modifiers, the result is the base.
+func BuildServingURL(base string, opts *URLOptions) (*url.URL, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		return u, nil
+	}
+	s, err := opts.suffix()
+	if err != nil {
+		return nil, err
+	}
+	if s != "" {
+		u.Path += "=" + s
+	}
+	return u, nil
+}
+
+// ParseServingURL splits a serving URL into its base, without modifiers, and
+// the options described by its modifiers. It returns an error if the URL has
+// a modifier that URLOptions does not represent.
+func ParseServingURL(s string) (base *url.URL, opts *URLOptions, err error) {
+	u, i, err := splitServingURL(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = &URLOptions{}
+	if i < 0 {
+		return u, opts, nil
+	}
+	mods := u.Path[i+1:]
+	u.Path = u.Path[:i]
+	if mods == "" {
+		return u, opts, nil
+	}
+	for _, m := range strings.Split(mods, "-") {
+		if err := opts.parse(m); err != nil {
+			return nil, nil, err
+		}
+	}
+	return u, opts, nil
+}
+
+// splitServingURL parses s and returns the index in its path of the "="
+// introducing the modifiers, or -1 if it has none.
+func splitServingURL(s string) (*url.URL, int, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Modifiers only ever follow the last path element.
+	i := strings.LastIndex(u.Path, "=")
+	if i < strings.LastIndex(u.Path, "/") {
+		i = -1
+	}
+	return u, i, nil
+}
+
+// parse sets the field of o described by the modifier m.
+func (o *URLOptions) parse(m string) error {
+	switch m {
+	case "c":
+		o.Crop = true
+		return nil
+	case "p":
+		o.SmartCrop = true
+		return nil
+	case "cc":
+		o.Circle = true
+		return nil
+	case "fh":
+		o.FlipHorizontal = true
+		return nil
+	case "fv":
+		o.FlipVertical = true
+		return nil
+	}
+	for f, fm := range formatOptions {
+		if m == fm {
+			o.Convert = true
+			o.Format = f
+			return nil
+		}
+	}
+	if len(m) > 1 {
+		n, err := strconv.Atoi(m[1:])
+		if err == nil && n >= 0 {
+			switch m[0] {
+			case 's':
+				o.Size = n
+				o.Original = n == 0
+				return nil
+			case 'w':
+				o.Width = n
+				return nil
+			case 'h':
+				o.Height = n
+				return nil
+			case 'r':
+				o.Rotate = n
+				return nil
+			case 'l':
+				o.Quality = n
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("image: unknown serving URL modifier %q", m)
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testBase = "https://lh3.googleusercontent.com/abc123"
+
+func TestBuildServingURL(t *testing.T) {
+	testCases := []struct {
+		opts *URLOptions
+		want string
+	}{
+		{nil, testBase},
+		{&URLOptions{}, testBase},
+		{&URLOptions{Size: 200, Crop: true}, testBase + "=s200-c"},
+		{&URLOptions{Original: true, Size: 200}, testBase + "=s0"},
+		{&URLOptions{Width: 300, Height: 100, SmartCrop: true}, testBase + "=w300-h100-p"},
+		{&URLOptions{Size: 64, Circle: true}, testBase + "=s64-cc"},
+		{&URLOptions{Rotate: -90, FlipHorizontal: true, FlipVertical: true}, testBase + "=r270-fh-fv"},
+		{&URLOptions{Rotate: 360}, testBase},
+		{&URLOptions{Convert: true, Format: JPEG, Quality: 85}, testBase + "=rj-l85"},
+		{&URLOptions{Convert: true, Format: PNG}, testBase + "=rp"},
+		{&URLOptions{Convert: true, Format: WEBP}, testBase + "=rw"},
+	}
+	for _, tc := range testCases {
+		u, err := BuildServingURL(testBase, tc.opts)
+		if err != nil {
+			t.Errorf("BuildServingURL(%+v): %v", tc.opts, err)
+			continue
+		}
+		if u.String() != tc.want {
+			t.Errorf("BuildServingURL(%+v) = %q, want %q", tc.opts, u, tc.want)
+		}
+	}
+
+	// Without modifiers, the base is returned unchanged, even if its last
+	// path element contains "=".
+	u, err := BuildServingURL(testBase+"=x", &URLOptions{})
+	if err != nil {
+		t.Fatalf("BuildServingURL: %v", err)
+	}
+	if want := testBase + "=x"; u.String() != want {
+		t.Errorf("BuildServingURL = %q, want %q", u, want)
+	}
+
+	// Existing modifiers are replaced by parsing the URL first.
+	base, _, err := ParseServingURL(testBase + "=s32-c")
+	if err != nil {
+		t.Fatalf("ParseServingURL: %v", err)
+	}
+	if u, err = BuildServingURL(base.String(), &URLOptions{Width: 10}); err != nil {
+		t.Fatalf("BuildServingURL: %v", err)
+	}
+	if want := testBase + "=w10"; u.String() != want {
+		t.Errorf("BuildServingURL = %q, want %q", u, want)
+	}
+}
+
+func TestBuildServingURLErrors(t *testing.T) {
+	for _, opts := range []*URLOptions{
+		{Size: -1},
+		{Width: -1},
+		{Rotate: 45},
+		{Quality: 101},
+		{Convert: true, Format: Format(99)},
+	} {
+		if _, err := BuildServingURL(testBase, opts); err == nil {
+			t.Errorf("BuildServingURL(%+v) succeeded", opts)
+		}
+	}
+}
+
+func TestParseServingURL(t *testing.T) {
+	testCases := []struct {
+		url  string
+		want *URLOptions
+	}{
+		{testBase, &URLOptions{}},
+		{testBase + "=", &URLOptions{}},
+		{testBase + "=s200-c", &URLOptions{Size: 200, Crop: true}},
+		{testBase + "=s0", &URLOptions{Original: true}},
+		{testBase + "=w300-h100-p-cc", &URLOptions{Width: 300, Height: 100, SmartCrop: true, Circle: true}},
+		{testBase + "=r90-fh-fv-rw-l70", &URLOptions{Rotate: 90, FlipHorizontal: true, FlipVertical: true, Convert: true, Format: WEBP, Quality: 70}},
+	}
+	for _, tc := range testCases {
+		base, opts, err := ParseServingURL(tc.url)
+		if err != nil {
+			t.Errorf("ParseServingURL(%q): %v", tc.url, err)
+			continue
+		}
+		if base.String() != testBase {
+			t.Errorf("ParseServingURL(%q) base = %q, want %q", tc.url, base, testBase)
+		}
+		if !reflect.DeepEqual(opts, tc.want) {
+			t.Errorf("ParseServingURL(%q) options = %+v, want %+v", tc.url, opts, tc.want)
+		}
+
+		// Building the URL from the parsed options round-trips.
+		u, err := BuildServingURL(base.String(), opts)
+		if err != nil {
+			t.Errorf("BuildServingURL(%+v): %v", opts, err)
+			continue
+		}
+		if got, want := u.String(), tc.url; got != want && got+"=" != want {
+			t.Errorf("round trip of %q = %q", tc.url, got)
+		}
+	}
+}
+
+func TestParseServingURLErrors(t *testing.T) {
+	for _, s := range []string{
+		testBase + "=x",
+		testBase + "=s-1",
+		testBase + "=sabc",
+		testBase + "=s32--c",
+	} {
+		if _, _, err := ParseServingURL(s); err == nil {
+			t.Errorf("ParseServingURL(%q) succeeded", s)
+		}
+	}
+}
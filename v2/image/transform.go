@@ -0,0 +1,401 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/image"
+)
+
+// Limits imposed by the images service.
+const (
+	// MaxDimension is the largest width or height of a resized image or
+	// composite canvas.
+	MaxDimension = 4000
+	// MaxLayers is the largest number of layers in a composite.
+	MaxLayers = 16
+)
+
+// Image is the source of a transform, composite or histogram. Exactly one of
+// Data, the encoded image, or BlobKey, a Blobstore or Cloud Storage blob
+// holding the encoded image, must be set.
+type Image struct {
+	Data    []byte
+	BlobKey appengine.BlobKey
+}
+
+func (img *Image) toProto() (*pb.ImageData, error) {
+	if img == nil || (len(img.Data) == 0) == (img.BlobKey == "") {
+		return nil, errors.New("image: exactly one of Data and BlobKey must be set")
+	}
+	d := &pb.ImageData{Content: img.Data}
+	if img.BlobKey != "" {
+		d.Content = []byte{}
+		d.BlobKey = proto.String(string(img.BlobKey))
+	}
+	return d, nil
+}
+
+// Format is an image encoding.
+type Format int
+
+// The image encodings supported by the images service.
+const (
+	PNG  Format = Format(pb.OutputSettings_PNG)
+	JPEG Format = Format(pb.OutputSettings_JPEG)
+	WEBP Format = Format(pb.OutputSettings_WEBP)
+)
+
+// outputProto returns the output settings for the given format and quality.
+func outputProto(f Format, quality int) (*pb.OutputSettings, error) {
+	if _, ok := pb.OutputSettings_MIME_TYPE_name[int32(f)]; !ok {
+		return nil, fmt.Errorf("image: unknown format %d", f)
+	}
+	o := &pb.OutputSettings{MimeType: pb.OutputSettings_MIME_TYPE(f).Enum()}
+	if quality != 0 {
+		if quality < 1 || quality > 100 {
+			return nil, fmt.Errorf("image: quality %d is not between 1 and 100", quality)
+		}
+		o.Quality = proto.Int32(int32(quality))
+	}
+	return o, nil
+}
+
+// Transform is a pipeline of operations to apply to an image, created with
+// NewTransform. Each method returns a derivative Transform with the operation
+// appended, leaving the receiver unchanged, so that a common pipeline can be
+// shared and extended. Errors in the arguments to the methods are reported by
+// Apply.
+type Transform struct {
+	ops                []*pb.Transform
+	format             Format
+	quality            int
+	correctOrientation bool
+
+	err error
+}
+
+// NewTransform returns an empty Transform whose output is encoded as PNG.
+func NewTransform() *Transform {
+	return &Transform{format: PNG}
+}
+
+func (t *Transform) clone() *Transform {
+	x := *t
+	x.ops = make([]*pb.Transform, len(t.ops))
+	copy(x.ops, t.ops)
+	return &x
+}
+
+// add returns a derivative Transform with op appended.
+func (t *Transform) add(op *pb.Transform) *Transform {
+	t = t.clone()
+	t.ops = append(t.ops, op)
+	return t
+}
+
+// fail returns a derivative Transform that reports err from Apply.
+func (t *Transform) fail(format string, args ...interface{}) *Transform {
+	t = t.clone()
+	if t.err == nil {
+		t.err = fmt.Errorf("image: "+format, args...)
+	}
+	return t
+}
+
+func validDimensions(width, height int) bool {
+	return width >= 0 && height >= 0 && width <= MaxDimension && height <= MaxDimension && width+height > 0
+}
+
+// Resize scales the image to fit within width by height pixels, preserving
+// its aspect ratio. Either dimension may be zero, in which case only the
+// other constrains the result.
+func (t *Transform) Resize(width, height int) *Transform {
+	if !validDimensions(width, height) {
+		return t.fail("invalid resize dimensions %dx%d", width, height)
+	}
+	return t.add(&pb.Transform{
+		Width:  proto.Int32(int32(width)),
+		Height: proto.Int32(int32(height)),
+	})
+}
+
+// ResizeToFill scales the image to exactly width by height pixels,
+// preserving its aspect ratio by cropping whatever does not fit. The
+// offsets, between 0 and 1, choose which part of the image is kept: 0.5,
+// 0.5 keeps the center and 0, 0 the top left.
+func (t *Transform) ResizeToFill(width, height int, offsetX, offsetY float64) *Transform {
+	if width <= 0 || height <= 0 || !validDimensions(width, height) {
+		return t.fail("invalid resize dimensions %dx%d", width, height)
+	}
+	if offsetX < 0 || offsetX > 1 || offsetY < 0 || offsetY > 1 {
+		return t.fail("crop offsets %v, %v are not between 0 and 1", offsetX, offsetY)
+	}
+	return t.add(&pb.Transform{
+		Width:       proto.Int32(int32(width)),
+		Height:      proto.Int32(int32(height)),
+		CropToFit:   proto.Bool(true),
+		CropOffsetX: proto.Float32(float32(offsetX)),
+		CropOffsetY: proto.Float32(float32(offsetY)),
+	})
+}
+
+// Stretch scales the image to exactly width by height pixels, without
+// preserving its aspect ratio.
+func (t *Transform) Stretch(width, height int) *Transform {
+	if width <= 0 || height <= 0 || !validDimensions(width, height) {
+		return t.fail("invalid stretch dimensions %dx%d", width, height)
+	}
+	return t.add(&pb.Transform{
+		Width:        proto.Int32(int32(width)),
+		Height:       proto.Int32(int32(height)),
+		AllowStretch: proto.Bool(true),
+	})
+}
+
+// Crop crops the image to the given bounding box. The coordinates are
+// fractions of the image's width and height, between 0 and 1, and left must
+// be less than right and top less than bottom.
+func (t *Transform) Crop(left, top, right, bottom float64) *Transform {
+	if left < 0 || top < 0 || right > 1 || bottom > 1 || left >= right || top >= bottom {
+		return t.fail("invalid crop box (%v, %v, %v, %v)", left, top, right, bottom)
+	}
+	return t.add(&pb.Transform{
+		CropLeftX:   proto.Float32(float32(left)),
+		CropTopY:    proto.Float32(float32(top)),
+		CropRightX:  proto.Float32(float32(right)),
+		CropBottomY: proto.Float32(float32(bottom)),
+	})
+}
+
+// Rotate rotates the image clockwise by the given number of degrees, which
+// must be a multiple of 90.
+func (t *Transform) Rotate(degrees int) *Transform {
+	if degrees%90 != 0 {
+		return t.fail("rotation %d is not a multiple of 90 degrees", degrees)
+	}
+	degrees %= 360
+	if degrees < 0 {
+		degrees += 360
+	}
+	return t.add(&pb.Transform{Rotate: proto.Int32(int32(degrees))})
+}
+
+// HorizontalFlip flips the image horizontally.
+func (t *Transform) HorizontalFlip() *Transform {
+	return t.add(&pb.Transform{HorizontalFlip: proto.Bool(true)})
+}
+
+// VerticalFlip flips the image vertically.
+func (t *Transform) VerticalFlip() *Transform {
+	return t.add(&pb.Transform{VerticalFlip: proto.Bool(true)})
+}
+
+// ImFeelingLucky adjusts the contrast, color levels and brightness of the
+// image automatically.
+func (t *Transform) ImFeelingLucky() *Transform {
+	return t.add(&pb.Transform{Autolevels: proto.Bool(true)})
+}
+
+// CorrectOrientation rotates the image according to its EXIF orientation
+// before applying the other operations.
+func (t *Transform) CorrectOrientation() *Transform {
+	t = t.clone()
+	t.correctOrientation = true
+	return t
+}
+
+// Encoding sets the format of the transformed image and, for JPEG and WEBP,
+// its quality between 1 and 100. A zero quality leaves the choice to the
+// service.
+func (t *Transform) Encoding(f Format, quality int) *Transform {
+	t = t.clone()
+	t.format = f
+	t.quality = quality
+	return t
+}
+
+// Apply applies the transform to img and returns the encoded result.
+func (t *Transform) Apply(c context.Context, img *Image) ([]byte, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if len(t.ops) == 0 && !t.correctOrientation {
+		return nil, errors.New("image: empty transform")
+	}
+	data, err := img.toProto()
+	if err != nil {
+		return nil, err
+	}
+	out, err := outputProto(t.format, t.quality)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.ImagesTransformRequest{
+		Image:     data,
+		Transform: t.ops,
+		Output:    out,
+	}
+	if t.correctOrientation {
+		req.Input = &pb.InputSettings{
+			CorrectExifOrientation: pb.InputSettings_CORRECT_ORIENTATION.Enum(),
+		}
+	}
+	res := &pb.ImagesTransformResponse{}
+	if err := internal.Call(c, "images", "Transform", req, res); err != nil {
+		return nil, err
+	}
+	return res.Image.GetContent(), nil
+}
+
+// Anchor is the point of the canvas and of a layer that are aligned, before
+// a layer's offset is applied, when compositing.
+type Anchor int
+
+// The anchor points.
+const (
+	TopLeft     Anchor = Anchor(pb.CompositeImageOptions_TOP_LEFT)
+	Top         Anchor = Anchor(pb.CompositeImageOptions_TOP)
+	TopRight    Anchor = Anchor(pb.CompositeImageOptions_TOP_RIGHT)
+	Left        Anchor = Anchor(pb.CompositeImageOptions_LEFT)
+	Center      Anchor = Anchor(pb.CompositeImageOptions_CENTER)
+	Right       Anchor = Anchor(pb.CompositeImageOptions_RIGHT)
+	BottomLeft  Anchor = Anchor(pb.CompositeImageOptions_BOTTOM_LEFT)
+	Bottom      Anchor = Anchor(pb.CompositeImageOptions_BOTTOM)
+	BottomRight Anchor = Anchor(pb.CompositeImageOptions_BOTTOM_RIGHT)
+)
+
+// Layer is an image to draw onto a composite canvas.
+type Layer struct {
+	Image *Image
+	// X and Y offset the layer from its anchor point, in pixels.
+	X, Y int
+	// Opacity is the layer's opacity, between 0 and 1 (opaque). As a
+	// zero Opacity makes the layer opaque, a fully transparent layer is
+	// requested by setting Transparent instead, in which case Opacity
+	// must be zero.
+	Opacity     float64
+	Transparent bool
+	Anchor      Anchor
+}
+
+// opacity returns the opacity with which the layer is drawn.
+func (l *Layer) opacity() float64 {
+	switch {
+	case l.Transparent:
+		return 0
+	case l.Opacity == 0:
+		return 1
+	}
+	return l.Opacity
+}
+
+// Canvas describes the output of a composite.
+type Canvas struct {
+	Width, Height int
+	// Color is the background color. If nil, the background is opaque
+	// white.
+	Color   color.Color
+	Format  Format
+	Quality int
+}
+
+// Composite draws the layers, in order, onto a canvas and returns the encoded
+// result. At most MaxLayers layers may be drawn.
+func Composite(c context.Context, canvas *Canvas, layers []Layer) ([]byte, error) {
+	if len(layers) == 0 || len(layers) > MaxLayers {
+		return nil, fmt.Errorf("image: composite has %d layers, want between 1 and %d", len(layers), MaxLayers)
+	}
+	if canvas.Width <= 0 || canvas.Height <= 0 || !validDimensions(canvas.Width, canvas.Height) {
+		return nil, fmt.Errorf("image: invalid canvas dimensions %dx%d", canvas.Width, canvas.Height)
+	}
+	out, err := outputProto(canvas.Format, canvas.Quality)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.ImagesCompositeRequest{
+		Canvas: &pb.ImagesCanvas{
+			Width:  proto.Int32(int32(canvas.Width)),
+			Height: proto.Int32(int32(canvas.Height)),
+			Output: out,
+		},
+	}
+	if canvas.Color != nil {
+		req.Canvas.Color = proto.Int32(colorToARGB(canvas.Color))
+	}
+	for i, l := range layers {
+		if l.Opacity < 0 || l.Opacity > 1 {
+			return nil, fmt.Errorf("image: layer %d opacity %v is not between 0 and 1", i, l.Opacity)
+		}
+		if l.Transparent && l.Opacity != 0 {
+			return nil, fmt.Errorf("image: layer %d is transparent but has opacity %v", i, l.Opacity)
+		}
+		if _, ok := pb.CompositeImageOptions_ANCHOR_name[int32(l.Anchor)]; !ok {
+			return nil, fmt.Errorf("image: layer %d has unknown anchor %d", i, l.Anchor)
+		}
+		data, err := l.Image.toProto()
+		if err != nil {
+			return nil, err
+		}
+		req.Image = append(req.Image, data)
+		req.Options = append(req.Options, &pb.CompositeImageOptions{
+			SourceIndex: proto.Int32(int32(i)),
+			XOffset:     proto.Int32(int32(l.X)),
+			YOffset:     proto.Int32(int32(l.Y)),
+			Opacity:     proto.Float32(float32(l.opacity())),
+			Anchor:      pb.CompositeImageOptions_ANCHOR(l.Anchor).Enum(),
+		})
+	}
+	res := &pb.ImagesCompositeResponse{}
+	if err := internal.Call(c, "images", "Composite", req, res); err != nil {
+		return nil, err
+	}
+	return res.Image.GetContent(), nil
+}
+
+// colorToARGB converts c to the 32-bit ARGB representation used by the
+// images service.
+func colorToARGB(c color.Color) int32 {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return int32(uint32(n.A)<<24 | uint32(n.R)<<16 | uint32(n.G)<<8 | uint32(n.B))
+}
+
+// Histogram holds the number of pixels of an image with each value, from 0
+// to 255, of each color channel.
+type Histogram struct {
+	Red, Green, Blue [256]int
+}
+
+// GetHistogram returns the color histogram of img.
+func GetHistogram(c context.Context, img *Image) (*Histogram, error) {
+	data, err := img.toProto()
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.ImagesHistogramRequest{Image: data}
+	res := &pb.ImagesHistogramResponse{}
+	if err := internal.Call(c, "images", "Histogram", req, res); err != nil {
+		return nil, err
+	}
+	h := &Histogram{}
+	copyCounts(&h.Red, res.Histogram.GetRed())
+	copyCounts(&h.Green, res.Histogram.GetGreen())
+	copyCounts(&h.Blue, res.Histogram.GetBlue())
+	return h, nil
+}
+
+func copyCounts(dst *[256]int, src []int32) {
+	for i := 0; i < len(src) && i < len(dst); i++ {
+		dst[i] = int(src[i])
+	}
+}
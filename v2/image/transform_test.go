@@ -0,0 +1,131 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package image
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/image"
+)
+
+func TestTransformRequest(t *testing.T) {
+	var got *pb.ImagesTransformRequest
+	c := aetesting.FakeSingleContext(t, "images", "Transform", func(req *pb.ImagesTransformRequest, res *pb.ImagesTransformResponse) error {
+		got = req
+		res.Image = &pb.ImageData{Content: []byte("out")}
+		return nil
+	})
+
+	base := NewTransform().Resize(100, 0)
+	tr := base.Rotate(-90).HorizontalFlip().Crop(0, 0.25, 1, 0.75).Encoding(JPEG, 80).CorrectOrientation()
+	out, err := tr.Apply(c, &Image{BlobKey: "blob"})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if string(out) != "out" {
+		t.Errorf("Apply = %q, want %q", out, "out")
+	}
+	want := &pb.ImagesTransformRequest{
+		Image: &pb.ImageData{Content: []byte{}, BlobKey: proto.String("blob")},
+		Transform: []*pb.Transform{
+			{Width: proto.Int32(100), Height: proto.Int32(0)},
+			{Rotate: proto.Int32(270)},
+			{HorizontalFlip: proto.Bool(true)},
+			{CropLeftX: proto.Float32(0), CropTopY: proto.Float32(0.25), CropRightX: proto.Float32(1), CropBottomY: proto.Float32(0.75)},
+		},
+		Output: &pb.OutputSettings{MimeType: pb.OutputSettings_JPEG.Enum(), Quality: proto.Int32(80)},
+		Input:  &pb.InputSettings{CorrectExifOrientation: pb.InputSettings_CORRECT_ORIENTATION.Enum()},
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("request:\ngot  %v\nwant %v", got, want)
+	}
+	if len(base.ops) != 1 {
+		t.Errorf("deriving a transform modified its base: %d ops, want 1", len(base.ops))
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		tr   *Transform
+		img  *Image
+	}{
+		{"empty", NewTransform(), &Image{Data: []byte("x")}},
+		{"rotate", NewTransform().Rotate(45), &Image{Data: []byte("x")}},
+		{"resize", NewTransform().Resize(0, MaxDimension+1), &Image{Data: []byte("x")}},
+		{"crop", NewTransform().Crop(0.5, 0, 0.5, 1), &Image{Data: []byte("x")}},
+		{"quality", NewTransform().HorizontalFlip().Encoding(JPEG, 101), &Image{Data: []byte("x")}},
+		{"no image", NewTransform().HorizontalFlip(), &Image{}},
+		{"two images", NewTransform().HorizontalFlip(), &Image{Data: []byte("x"), BlobKey: "blob"}},
+	}
+	for _, tc := range testCases {
+		// No call should be made, so any context will do.
+		if _, err := tc.tr.Apply(nil, tc.img); err == nil {
+			t.Errorf("%s: got nil error", tc.desc)
+		}
+	}
+}
+
+func TestCompositeRequest(t *testing.T) {
+	var got *pb.ImagesCompositeRequest
+	c := aetesting.FakeSingleContext(t, "images", "Composite", func(req *pb.ImagesCompositeRequest, res *pb.ImagesCompositeResponse) error {
+		got = req
+		res.Image = &pb.ImageData{Content: []byte("out")}
+		return nil
+	})
+	canvas := &Canvas{Width: 200, Height: 100, Color: color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}}
+	layers := []Layer{
+		{Image: &Image{Data: []byte("a")}, Anchor: TopLeft},
+		{Image: &Image{BlobKey: "b"}, X: -5, Y: 10, Opacity: 0.5, Anchor: Center},
+	}
+	if _, err := Composite(c, canvas, layers); err != nil {
+		t.Fatalf("Composite: %v", err)
+	}
+	if n := got.Canvas.GetColor(); uint32(n) != 0xff123456 {
+		t.Errorf("canvas color = %#x, want 0xff123456", uint32(n))
+	}
+	if len(got.Image) != 2 || len(got.Options) != 2 {
+		t.Fatalf("got %d images and %d options, want 2 and 2", len(got.Image), len(got.Options))
+	}
+	if o := got.Options[0]; o.GetOpacity() != 1 {
+		t.Errorf("layer 0 opacity = %v, want 1 by default", o.GetOpacity())
+	}
+	o := got.Options[1]
+	if o.GetSourceIndex() != 1 || o.GetXOffset() != -5 || o.GetYOffset() != 10 || o.GetOpacity() != 0.5 || o.GetAnchor() != pb.CompositeImageOptions_CENTER {
+		t.Errorf("layer 1 options = %v", o)
+	}
+
+	if _, err := Composite(c, canvas, nil); err == nil {
+		t.Error("Composite with no layers: got nil error")
+	}
+	layers = []Layer{{Image: &Image{BlobKey: "b"}, Opacity: 0.5, Transparent: true}}
+	if _, err := Composite(c, canvas, layers); err == nil {
+		t.Error("Composite with a transparent layer with an opacity: got nil error")
+	}
+}
+
+func TestGetHistogram(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "images", "Histogram", func(req *pb.ImagesHistogramRequest, res *pb.ImagesHistogramResponse) error {
+		h := &pb.ImagesHistogram{
+			Red:   make([]int32, 256),
+			Green: make([]int32, 256),
+			Blue:  make([]int32, 256),
+		}
+		h.Red[255], h.Green[0], h.Blue[128] = 4, 4, 4
+		res.Histogram = h
+		return nil
+	})
+	h, err := GetHistogram(c, &Image{Data: []byte("x")})
+	if err != nil {
+		t.Fatalf("GetHistogram: %v", err)
+	}
+	if h.Red[255] != 4 || h.Green[0] != 4 || h.Blue[128] != 4 || h.Red[0] != 0 {
+		t.Errorf("unexpected histogram %v", h)
+	}
+}
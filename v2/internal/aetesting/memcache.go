@@ -0,0 +1,320 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package aetesting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/memcache"
+)
+
+// secondsIn30Years is the threshold above which memcache expiration times
+// are absolute Unix times rather than durations.
+const secondsIn30Years = 60 * 60 * 24 * 365 * 30
+
+// maxValueSize is the maximum size of a memcache value. Like the real
+// service, Memcache fails to store larger values.
+const maxValueSize = 1000000
+
+// Memcache is an in-memory implementation of the memcache service. Unlike
+// FakeSingleContext, it keeps state across calls, so it can be used to test
+// code that combines several memcache operations.
+type Memcache struct {
+	mu sync.Mutex
+	// items maps a namespace and key to an item.
+	items map[string]map[string]*memcacheItem
+	// clock is incremented on every access and orders items for GrabTail.
+	clock  uint64
+	casID  uint64
+	hits   uint64
+	misses uint64
+
+	// Now returns the current time. It can be replaced to test expiration.
+	Now func() time.Time
+}
+
+type memcacheItem struct {
+	value      []byte
+	flags      uint32
+	casID      uint64
+	expires    time.Time
+	lastAccess time.Time
+	access     uint64
+}
+
+// NewMemcache returns an empty Memcache.
+func NewMemcache() *Memcache {
+	return &Memcache{
+		items: make(map[string]map[string]*memcacheItem),
+		Now:   time.Now,
+	}
+}
+
+// Context returns a context whose memcache calls are serviced by m.
+func (m *Memcache) Context() context.Context {
+	return internal.WithCallOverride(internal.ContextForTesting(&http.Request{}), m.Call)
+}
+
+// Call services a single memcache API call. It has the signature expected
+// by internal.WithCallOverride.
+func (m *Memcache) Call(ctx context.Context, service, method string, in, out proto.Message) error {
+	if service == "__go__" && method == "GetNamespace" {
+		return nil // always yield an empty namespace
+	}
+	if service != "memcache" {
+		return fmt.Errorf("Unknown API call /%s.%s", service, method)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch method {
+	case "Get":
+		m.get(in.(*pb.MemcacheGetRequest), out.(*pb.MemcacheGetResponse))
+	case "Set":
+		m.set(in.(*pb.MemcacheSetRequest), out.(*pb.MemcacheSetResponse))
+	case "Delete":
+		m.delete(in.(*pb.MemcacheDeleteRequest), out.(*pb.MemcacheDeleteResponse))
+	case "Increment":
+		res := out.(*pb.MemcacheIncrementResponse)
+		*res = *m.increment(in.(*pb.MemcacheIncrementRequest))
+		if res.GetIncrementStatus() == pb.MemcacheIncrementResponse_ERROR {
+			return &internal.APIError{Service: service, Code: int32(pb.MemcacheServiceError_INVALID_VALUE)}
+		}
+	case "BatchIncrement":
+		req, res := in.(*pb.MemcacheBatchIncrementRequest), out.(*pb.MemcacheBatchIncrementResponse)
+		for _, r := range req.Item {
+			// Like the real service, leave the caller's request unchanged.
+			r = proto.Clone(r).(*pb.MemcacheIncrementRequest)
+			if r.NameSpace == nil {
+				r.NameSpace = req.NameSpace
+			}
+			res.Item = append(res.Item, m.increment(r))
+		}
+	case "GrabTail":
+		m.grabTail(in.(*pb.MemcacheGrabTailRequest), out.(*pb.MemcacheGrabTailResponse))
+	case "FlushAll":
+		m.items = make(map[string]map[string]*memcacheItem)
+	case "Stats":
+		m.stats(out.(*pb.MemcacheStatsResponse))
+	default:
+		return fmt.Errorf("Unknown API call /%s.%s", service, method)
+	}
+	return nil
+}
+
+// lookup returns the unexpired item for key in namespace ns, or nil.
+func (m *Memcache) lookup(ns string, key []byte) *memcacheItem {
+	it := m.items[ns][string(key)]
+	if it == nil {
+		return nil
+	}
+	if !it.expires.IsZero() && !m.Now().Before(it.expires) {
+		delete(m.items[ns], string(key))
+		return nil
+	}
+	return it
+}
+
+// store stores it under key in namespace ns.
+func (m *Memcache) store(ns string, key []byte, it *memcacheItem) {
+	if m.items[ns] == nil {
+		m.items[ns] = make(map[string]*memcacheItem)
+	}
+	m.casID++
+	it.casID = m.casID
+	m.touch(it)
+	m.items[ns][string(key)] = it
+}
+
+// touch records an access to it.
+func (m *Memcache) touch(it *memcacheItem) {
+	m.clock++
+	it.access = m.clock
+	it.lastAccess = m.Now()
+}
+
+func (m *Memcache) get(req *pb.MemcacheGetRequest, res *pb.MemcacheGetResponse) {
+	for _, k := range req.Key {
+		it := m.lookup(req.GetNameSpace(), k)
+		if it == nil {
+			m.misses++
+			continue
+		}
+		m.hits++
+		r := &pb.MemcacheGetResponse_Item{
+			Key:   k,
+			Value: it.value,
+			Flags: proto.Uint32(it.flags),
+		}
+		if req.GetForCas() {
+			r.CasId = proto.Uint64(it.casID)
+		}
+		if req.GetForPeek() {
+			r.Timestamps = &pb.ItemTimestamps{
+				LastAccessTimeSec: proto.Int64(it.lastAccess.Unix()),
+			}
+			if !it.expires.IsZero() {
+				r.Timestamps.ExpirationTimeSec = proto.Int64(it.expires.Unix())
+			}
+		} else {
+			m.touch(it)
+		}
+		res.Item = append(res.Item, r)
+	}
+}
+
+func (m *Memcache) set(req *pb.MemcacheSetRequest, res *pb.MemcacheSetResponse) {
+	ns := req.GetNameSpace()
+	for _, r := range req.Item {
+		if len(r.Value) > maxValueSize {
+			res.SetStatus = append(res.SetStatus, pb.MemcacheSetResponse_ERROR)
+			continue
+		}
+		old := m.lookup(ns, r.Key)
+		status := pb.MemcacheSetResponse_STORED
+		switch r.GetSetPolicy() {
+		case pb.MemcacheSetRequest_ADD:
+			if old != nil {
+				status = pb.MemcacheSetResponse_NOT_STORED
+			}
+		case pb.MemcacheSetRequest_REPLACE:
+			if old == nil {
+				status = pb.MemcacheSetResponse_NOT_STORED
+			}
+		case pb.MemcacheSetRequest_CAS:
+			if old == nil {
+				status = pb.MemcacheSetResponse_NOT_STORED
+			} else if old.casID != r.GetCasId() {
+				status = pb.MemcacheSetResponse_EXISTS
+			}
+		}
+		if status == pb.MemcacheSetResponse_STORED {
+			m.store(ns, r.Key, &memcacheItem{
+				value:   r.Value,
+				flags:   r.GetFlags(),
+				expires: m.expiry(r.GetExpirationTime()),
+			})
+		}
+		res.SetStatus = append(res.SetStatus, status)
+	}
+}
+
+// expiry converts a memcache expiration time to an absolute time.
+func (m *Memcache) expiry(sec uint32) time.Time {
+	switch {
+	case sec == 0:
+		return time.Time{}
+	case sec < secondsIn30Years:
+		return m.Now().Add(time.Duration(sec) * time.Second)
+	default:
+		return time.Unix(int64(sec), 0)
+	}
+}
+
+func (m *Memcache) delete(req *pb.MemcacheDeleteRequest, res *pb.MemcacheDeleteResponse) {
+	ns := req.GetNameSpace()
+	for _, r := range req.Item {
+		status := pb.MemcacheDeleteResponse_NOT_FOUND
+		if m.lookup(ns, r.Key) != nil {
+			delete(m.items[ns], string(r.Key))
+			status = pb.MemcacheDeleteResponse_DELETED
+		}
+		res.DeleteStatus = append(res.DeleteStatus, status)
+	}
+}
+
+func (m *Memcache) increment(req *pb.MemcacheIncrementRequest) *pb.MemcacheIncrementResponse {
+	ns := req.GetNameSpace()
+	it := m.lookup(ns, req.Key)
+	var v uint64
+	if it == nil {
+		if req.InitialValue == nil {
+			return &pb.MemcacheIncrementResponse{
+				IncrementStatus: pb.MemcacheIncrementResponse_NOT_CHANGED.Enum(),
+			}
+		}
+		v = req.GetInitialValue()
+		it = &memcacheItem{flags: req.GetInitialFlags()}
+	} else {
+		var err error
+		if v, err = strconv.ParseUint(string(it.value), 10, 64); err != nil {
+			return &pb.MemcacheIncrementResponse{
+				IncrementStatus: pb.MemcacheIncrementResponse_ERROR.Enum(),
+			}
+		}
+	}
+	if req.GetDirection() == pb.MemcacheIncrementRequest_DECREMENT {
+		if d := req.GetDelta(); d > v {
+			v = 0
+		} else {
+			v -= d
+		}
+	} else {
+		v += req.GetDelta()
+	}
+	it.value = []byte(strconv.FormatUint(v, 10))
+	m.store(ns, req.Key, it)
+	return &pb.MemcacheIncrementResponse{
+		NewValue:        proto.Uint64(v),
+		IncrementStatus: pb.MemcacheIncrementResponse_OK.Enum(),
+	}
+}
+
+func (m *Memcache) grabTail(req *pb.MemcacheGrabTailRequest, res *pb.MemcacheGrabTailResponse) {
+	ns := req.GetNameSpace()
+	for n := req.GetItemCount(); n > 0; n-- {
+		var (
+			tailKey string
+			tail    *memcacheItem
+		)
+		for k := range m.items[ns] {
+			it := m.lookup(ns, []byte(k))
+			if it != nil && (tail == nil || it.access < tail.access) {
+				tailKey, tail = k, it
+			}
+		}
+		if tail == nil {
+			return
+		}
+		delete(m.items[ns], tailKey)
+		res.Item = append(res.Item, &pb.MemcacheGrabTailResponse_Item{
+			Value: tail.value,
+			Flags: proto.Uint32(tail.flags),
+		})
+	}
+}
+
+func (m *Memcache) stats(res *pb.MemcacheStatsResponse) {
+	var items, bytes uint64
+	oldest := m.Now()
+	for ns := range m.items {
+		for k := range m.items[ns] {
+			it := m.lookup(ns, []byte(k))
+			if it == nil {
+				continue
+			}
+			items++
+			bytes += uint64(len(k) + len(it.value))
+			if it.lastAccess.Before(oldest) {
+				oldest = it.lastAccess
+			}
+		}
+	}
+	res.Stats = &pb.MergedNamespaceStats{
+		Hits:          proto.Uint64(m.hits),
+		Misses:        proto.Uint64(m.misses),
+		ByteHits:      proto.Uint64(0),
+		Items:         proto.Uint64(items),
+		Bytes:         proto.Uint64(bytes),
+		OldestItemAge: proto.Uint32(uint32(m.Now().Sub(oldest) / time.Second)),
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"sync/atomic"
 
 	"github.com/golang/protobuf/proto"
 
@@ -47,9 +48,26 @@ func withTransaction(ctx context.Context, t *transaction) context.Context {
 	return context.WithValue(ctx, &transactionKey, t)
 }
 
+// InTransaction reports whether ctx is a transaction context.
+func InTransaction(ctx context.Context) bool {
+	return transactionFromContext(ctx) != nil
+}
+
 type transaction struct {
 	transaction pb.Transaction
 	finished    bool
+	tasks       int32 // transactional tasks added; accessed atomically
+}
+
+// AddTransactionalTasks adds n, which may be negative, to the number of
+// tasks added in the transaction of ctx, and returns the new number.
+// It returns 0 if ctx is not a transaction context.
+func AddTransactionalTasks(ctx context.Context, n int) int {
+	t := transactionFromContext(ctx)
+	if t == nil {
+		return 0
+	}
+	return int(atomic.AddInt32(&t.tasks, int32(n)))
 }
 
 var ErrConcurrentTransaction = errors.New("internal: concurrent transaction")
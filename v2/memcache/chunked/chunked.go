@@ -0,0 +1,273 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+/*
+Package chunked stores values larger than the memcache item size limit by
+splitting them across several memcache items.
+
+A value stored under a key is split into chunks of at most ChunkSize bytes,
+which are stored under keys derived from a SHA-256 hash of the key and
+starting with ReservedPrefix, so that they cannot collide with other keys.
+The key itself holds a small manifest recording the number of chunks, the
+length of the value and a SHA-256 hash of its contents. The chunks are
+written with SetMulti calls small enough for the API call size limit, the
+manifest last, and read back with a Get of the manifest followed by
+GetMulti calls for the chunks.
+
+Memcache may evict any of the items independently, and concurrent writers
+may interleave their chunks. Get detects both cases, because a chunk is
+missing or the value's hash does not match the manifest, and reports them
+as memcache.ErrCacheMiss, so callers can treat a chunked value exactly like
+an ordinary cached value.
+
+Example:
+
+	item := &memcache.Item{
+		Key:        "page:/index.html",
+		Value:      renderedPage,
+		Expiration: time.Hour,
+	}
+	if err := chunked.Set(ctx, item); err != nil {
+		return err
+	}
+	...
+	item, err := chunked.Get(ctx, "page:/index.html")
+	if err == memcache.ErrCacheMiss {
+		// Render the page again.
+	}
+*/
+package chunked // import "google.golang.org/appengine/v2/memcache/chunked"
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/memcache"
+)
+
+// ChunkSize is the maximum number of bytes of a value stored in each chunk.
+// It leaves room below the memcache limit of 1,000,000 bytes per value for
+// the key and the item's metadata.
+const ChunkSize = 1000000 - 1024
+
+// MaxKeyLen is the maximum length of a key.
+const MaxKeyLen = 250
+
+// ReservedPrefix starts the keys of all chunks. Keys passed to this package
+// must not start with it.
+const ReservedPrefix = "\x00chunked:"
+
+// maxBatchSize is the maximum number of bytes of chunk values written or read
+// by one memcache call, which leaves room below the 32 MB limit on the size
+// of API calls. It is a variable for testing.
+var maxBatchSize = 30 << 20
+
+// ErrInvalidManifest is returned by Get and Delete when the item stored
+// under the key was not written by Set.
+var ErrInvalidManifest = errors.New("chunked: invalid manifest")
+
+// manifestMagic prefixes every manifest so that other values are not
+// mistaken for one.
+var manifestMagic = []byte("chunked1")
+
+// manifest describes a value that has been split into chunks.
+type manifest struct {
+	chunks int
+	size   int
+	hash   [sha256.Size]byte
+}
+
+// manifestLen is the length of an encoded manifest.
+const manifestLen = 8 + 4 + 8 + sha256.Size
+
+func (m *manifest) marshal() []byte {
+	b := make([]byte, manifestLen)
+	n := copy(b, manifestMagic)
+	binary.BigEndian.PutUint32(b[n:], uint32(m.chunks))
+	binary.BigEndian.PutUint64(b[n+4:], uint64(m.size))
+	copy(b[n+12:], m.hash[:])
+	return b
+}
+
+func (m *manifest) unmarshal(b []byte) error {
+	if len(b) != manifestLen || !bytes.HasPrefix(b, manifestMagic) {
+		return ErrInvalidManifest
+	}
+	b = b[len(manifestMagic):]
+	m.chunks = int(binary.BigEndian.Uint32(b))
+	m.size = int(binary.BigEndian.Uint64(b[4:]))
+	copy(m.hash[:], b[12:])
+	if m.size > m.chunks*ChunkSize {
+		return ErrInvalidManifest
+	}
+	return nil
+}
+
+// chunkKey returns the key of the i'th chunk of the value stored under key.
+func chunkKey(key string, i int) string {
+	h := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("%s%s:%d", ReservedPrefix, hex.EncodeToString(h[:]), i)
+}
+
+func checkKey(key string) error {
+	if len(key) > MaxKeyLen {
+		return fmt.Errorf("chunked: key %q is longer than %d bytes", key, MaxKeyLen)
+	}
+	if strings.HasPrefix(key, ReservedPrefix) {
+		return fmt.Errorf("chunked: key %q starts with the reserved prefix", key)
+	}
+	return nil
+}
+
+// batches splits n chunks into batches of at most maxBatchSize bytes, and
+// returns the index of the first chunk of each batch followed by n.
+func batches(n int) []int {
+	perBatch := maxBatchSize / ChunkSize
+	if perBatch < 1 {
+		perBatch = 1
+	}
+	var b []int
+	for i := 0; i < n; i += perBatch {
+		b = append(b, i)
+	}
+	return append(b, n)
+}
+
+func firstError(err error) error {
+	if me, ok := err.(appengine.MultiError); ok {
+		for _, err := range me {
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// Set writes the given item, unconditionally, splitting its value into
+// chunks. The item's Flags and Expiration apply to the manifest and to all
+// the chunks. The key must be at most MaxKeyLen bytes in length and must not
+// start with ReservedPrefix.
+func Set(c context.Context, item *memcache.Item) error {
+	if err := checkKey(item.Key); err != nil {
+		return err
+	}
+	m := manifest{
+		chunks: (len(item.Value) + ChunkSize - 1) / ChunkSize,
+		size:   len(item.Value),
+		hash:   sha256.Sum256(item.Value),
+	}
+	items := make([]*memcache.Item, 0, m.chunks)
+	for i := 0; i < m.chunks; i++ {
+		end := (i + 1) * ChunkSize
+		if end > len(item.Value) {
+			end = len(item.Value)
+		}
+		items = append(items, &memcache.Item{
+			Key:        chunkKey(item.Key, i),
+			Value:      item.Value[i*ChunkSize : end],
+			Flags:      item.Flags,
+			Expiration: item.Expiration,
+		})
+	}
+	b := batches(len(items))
+	for i := 0; i+1 < len(b); i++ {
+		if err := memcache.SetMulti(c, items[b[i]:b[i+1]]); err != nil {
+			return firstError(err)
+		}
+	}
+	// The manifest is written last, so that it never refers to chunks that
+	// failed to be written.
+	return memcache.Set(c, &memcache.Item{
+		Key:        item.Key,
+		Value:      m.marshal(),
+		Flags:      item.Flags,
+		Expiration: item.Expiration,
+	})
+}
+
+// Get gets the item for the given key, reassembling its value from its
+// chunks and verifying its integrity. memcache.ErrCacheMiss is returned if
+// the manifest or any of the chunks is missing, or if the reassembled value
+// does not match the manifest.
+func Get(c context.Context, key string) (*memcache.Item, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	mi, err := memcache.Get(c, key)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := m.unmarshal(mi.Value); err != nil {
+		return nil, err
+	}
+	keys := make([]string, m.chunks)
+	for i := range keys {
+		keys[i] = chunkKey(key, i)
+	}
+	value := make([]byte, 0, m.size)
+	b := batches(len(keys))
+	for i := 0; i+1 < len(b); i++ {
+		batch := keys[b[i]:b[i+1]]
+		chunks, err := memcache.GetMulti(c, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range batch {
+			ci, ok := chunks[k]
+			if !ok {
+				return nil, memcache.ErrCacheMiss
+			}
+			value = append(value, ci.Value...)
+		}
+	}
+	if len(value) != m.size || sha256.Sum256(value) != m.hash {
+		return nil, memcache.ErrCacheMiss
+	}
+	return &memcache.Item{
+		Key:   key,
+		Value: value,
+		Flags: mi.Flags,
+	}, nil
+}
+
+// Delete deletes the item for the given key and all of its chunks.
+// memcache.ErrCacheMiss is returned if the manifest can not be found.
+func Delete(c context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	mi, err := memcache.Get(c, key)
+	if err != nil {
+		return err
+	}
+	var m manifest
+	if err := m.unmarshal(mi.Value); err != nil {
+		return err
+	}
+	keys := make([]string, 0, m.chunks+1)
+	keys = append(keys, key)
+	for i := 0; i < m.chunks; i++ {
+		keys = append(keys, chunkKey(key, i))
+	}
+	err = memcache.DeleteMulti(c, keys)
+	if me, ok := err.(appengine.MultiError); ok {
+		// Chunks that have already been evicted are not an error.
+		for _, err := range me {
+			if err != nil && err != memcache.ErrCacheMiss {
+				return err
+			}
+		}
+		return me[0]
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package chunked
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/memcache"
+	"google.golang.org/appengine/v2/memcache"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	for _, n := range []int{0, 10, ChunkSize, 2*ChunkSize + 7} {
+		value := bytes.Repeat([]byte{byte(n)}, n)
+		if err := Set(c, &memcache.Item{Key: "k", Value: value, Flags: 5}); err != nil {
+			t.Fatalf("Set(%d bytes): %v", n, err)
+		}
+		item, err := Get(c, "k")
+		if err != nil {
+			t.Fatalf("Get(%d bytes): %v", n, err)
+		}
+		if !bytes.Equal(item.Value, value) || item.Flags != 5 {
+			t.Errorf("Get(%d bytes): got %d bytes with flags %d", n, len(item.Value), item.Flags)
+		}
+	}
+}
+
+func TestValueOverMemcacheLimit(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	value := bytes.Repeat([]byte("v"), 2500000)
+	if err := memcache.Set(c, &memcache.Item{Key: "plain", Value: value}); err == nil {
+		t.Fatal("memcache.Set of a value over the memcache limit succeeded")
+	}
+	if err := Set(c, &memcache.Item{Key: "k", Value: value}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if item, err := Get(c, "k"); err != nil || !bytes.Equal(item.Value, value) {
+		t.Errorf("Get: got %v, want the value", err)
+	}
+}
+
+func TestMissingAndCorruptChunks(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	value := bytes.Repeat([]byte("x"), 2*ChunkSize)
+	if err := Set(c, &memcache.Item{Key: "k", Value: value}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A chunk from a different value makes the hash mismatch.
+	if err := memcache.Set(c, &memcache.Item{Key: chunkKey("k", 1), Value: bytes.Repeat([]byte("y"), ChunkSize)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(c, "k"); err != memcache.ErrCacheMiss {
+		t.Errorf("Get with a corrupt chunk: got %v want %v", err, memcache.ErrCacheMiss)
+	}
+
+	if err := memcache.Delete(c, chunkKey("k", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(c, "k"); err != memcache.ErrCacheMiss {
+		t.Errorf("Get with a missing chunk: got %v want %v", err, memcache.ErrCacheMiss)
+	}
+
+	// Delete succeeds even though a chunk has already gone.
+	if err := Delete(c, "k"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if _, err := memcache.Get(c, chunkKey("k", 0)); err != memcache.ErrCacheMiss {
+		t.Errorf("chunk 0 after Delete: got %v want %v", err, memcache.ErrCacheMiss)
+	}
+
+	if err := memcache.Set(c, &memcache.Item{Key: "plain", Value: []byte("v")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(c, "plain"); err != ErrInvalidManifest {
+		t.Errorf("Get of a plain item: got %v want %v", err, ErrInvalidManifest)
+	}
+}
+
+func TestChunkKeysDoNotCollide(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	value := bytes.Repeat([]byte("v"), ChunkSize+1)
+	if err := Set(c, &memcache.Item{Key: "k", Value: value}); err != nil {
+		t.Fatal(err)
+	}
+	// Keys that the old "key#N" chunk scheme used are ordinary keys.
+	if err := memcache.Set(c, &memcache.Item{Key: "k#1", Value: []byte("other")}); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := Get(c, "k"); err != nil || !bytes.Equal(item.Value, value) {
+		t.Errorf("Get after writing k#1: got %v, want the original value", err)
+	}
+	if err := Set(c, &memcache.Item{Key: chunkKey("k", 0), Value: value}); err == nil {
+		t.Error("Set of a key with the reserved prefix succeeded")
+	}
+}
+
+func TestBatches(t *testing.T) {
+	defer func(n int) { maxBatchSize = n }(maxBatchSize)
+	maxBatchSize = 2 * ChunkSize
+
+	var sets []int
+	c := internal.WithCallOverride(aetesting.NewMemcache().Context(), func(ctx context.Context, service, method string, in, out proto.Message) error {
+		if method == "Set" {
+			sets = append(sets, len(in.(*pb.MemcacheSetRequest).Item))
+		}
+		return internal.Call(ctx, service, method, in, out)
+	})
+	value := bytes.Repeat([]byte("v"), 4*ChunkSize+1)
+	if err := Set(c, &memcache.Item{Key: "k", Value: value}); err != nil {
+		t.Fatal(err)
+	}
+	// Five chunks in batches of two, then the manifest.
+	if want := []int{2, 2, 1, 1}; !reflect.DeepEqual(sets, want) {
+		t.Errorf("Set wrote batches of %v items, want %v", sets, want)
+	}
+	if item, err := Get(c, "k"); err != nil || !bytes.Equal(item.Value, value) {
+		t.Errorf("Get: %v", err)
+	}
+}
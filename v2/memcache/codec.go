@@ -0,0 +1,154 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "google.golang.org/appengine/v2/internal/memcache"
+)
+
+// Proto is a Codec that uses the proto package. The values passed to it must
+// implement proto.Message.
+var Proto = Codec{protoMarshal, protoUnmarshal}
+
+func protoMarshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("memcache: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func protoUnmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("memcache: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// CompressedFlag is the bit of Item.Flags that a CompressedCodec sets on
+// items whose values it has compressed. Applications that use a
+// CompressedCodec must not use this bit for their own purposes.
+const CompressedFlag uint32 = 1 << 31
+
+// DefaultCompressionThreshold is the default value of
+// CompressedCodec.Threshold.
+const DefaultCompressionThreshold = 16 << 10
+
+// CompressedCodec is a Codec that gzip-compresses marshaled values larger
+// than Threshold bytes before storing them, and marks such items with
+// CompressedFlag so that they are decompressed when read. Items without the
+// flag are read as they are, so a CompressedCodec can read values written
+// by its underlying Codec.
+//
+// Compression lets values that marshal to slightly more than the memcache
+// item size limit be stored, and reduces the bandwidth used by large values.
+//
+// Its methods behave like those of Codec with the same name, and it may be
+// passed as a ValueCodec to GetAs, GetMultiAs and Update.
+type CompressedCodec struct {
+	Codec
+	// Threshold is the size in bytes above which marshaled values are
+	// compressed. If zero, DefaultCompressionThreshold is used.
+	Threshold int
+}
+
+// Get is like Codec.Get, decompressing the value if needed.
+func (cd CompressedCodec) Get(c context.Context, key string, v interface{}) (*Item, error) {
+	return codecGet(c, cd, key, v)
+}
+
+func (cd CompressedCodec) decode(item *Item, v interface{}) error {
+	b := item.Value
+	if item.Flags&CompressedFlag != 0 {
+		var err error
+		if b, err = gunzip(b); err != nil {
+			return err
+		}
+	}
+	return cd.Unmarshal(b, v)
+}
+
+func (cd CompressedCodec) encode(item *Item) (*Item, []byte, error) {
+	threshold := cd.Threshold
+	if threshold == 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	v, err := cd.Marshal(item.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The item is copied so that the caller's flags are not modified.
+	cp := *item
+	cp.Flags &^= CompressedFlag
+	if len(v) > threshold {
+		if v, err = gzipBytes(v); err != nil {
+			return nil, nil, err
+		}
+		cp.Flags |= CompressedFlag
+	}
+	return &cp, v, nil
+}
+
+// Set is like Codec.Set, compressing the value if needed.
+func (cd CompressedCodec) Set(c context.Context, item *Item) error {
+	return singleError(codecSet(c, cd, []*Item{item}, pb.MemcacheSetRequest_SET))
+}
+
+// SetMulti is like Codec.SetMulti, compressing the values if needed.
+func (cd CompressedCodec) SetMulti(c context.Context, items []*Item) error {
+	return codecSet(c, cd, items, pb.MemcacheSetRequest_SET)
+}
+
+// Add is like Codec.Add, compressing the value if needed.
+func (cd CompressedCodec) Add(c context.Context, item *Item) error {
+	return singleError(codecSet(c, cd, []*Item{item}, pb.MemcacheSetRequest_ADD))
+}
+
+// AddMulti is like Codec.AddMulti, compressing the values if needed.
+func (cd CompressedCodec) AddMulti(c context.Context, items []*Item) error {
+	return codecSet(c, cd, items, pb.MemcacheSetRequest_ADD)
+}
+
+// CompareAndSwap is like Codec.CompareAndSwap, compressing the value if
+// needed.
+func (cd CompressedCodec) CompareAndSwap(c context.Context, item *Item) error {
+	return singleError(codecSet(c, cd, []*Item{item}, pb.MemcacheSetRequest_CAS))
+}
+
+// CompareAndSwapMulti is like Codec.CompareAndSwapMulti, compressing the
+// values if needed.
+func (cd CompressedCodec) CompareAndSwapMulti(c context.Context, items []*Item) error {
+	return codecSet(c, cd, items, pb.MemcacheSetRequest_CAS)
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package memcache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/memcache"
+)
+
+func TestProtoCodec(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	in := &pb.MemcacheGrabTailRequest{ItemCount: proto.Int32(3), NameSpace: proto.String("ns")}
+	if err := Proto.Set(c, &Item{Key: "k", Object: in}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	out := &pb.MemcacheGrabTailRequest{}
+	if _, err := Proto.Get(c, "k", out); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !proto.Equal(in, out) {
+		t.Errorf("got %v want %v", out, in)
+	}
+	if err := Proto.Set(c, &Item{Key: "k", Object: "not a message"}); err == nil {
+		t.Error("Set of a non-message: got nil error")
+	}
+}
+
+func TestCompressedCodec(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	cd := CompressedCodec{Codec: JSON, Threshold: 100}
+	small, large := "small", strings.Repeat("large ", 1000)
+
+	for _, tc := range []struct {
+		key, value string
+		compressed bool
+	}{
+		{"small", small, false},
+		{"large", large, true},
+	} {
+		if err := cd.Set(c, &Item{Key: tc.key, Object: tc.value, Flags: 1}); err != nil {
+			t.Fatalf("Set(%q): %v", tc.key, err)
+		}
+		raw, err := Get(c, tc.key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", tc.key, err)
+		}
+		if got := raw.Flags&CompressedFlag != 0; got != tc.compressed {
+			t.Errorf("%q: compressed flag = %v, want %v", tc.key, got, tc.compressed)
+		}
+		if raw.Flags&1 == 0 {
+			t.Errorf("%q: application flags were not kept", tc.key)
+		}
+		if tc.compressed && len(raw.Value) >= len(tc.value) {
+			t.Errorf("%q: stored %d bytes for a %d byte value", tc.key, len(raw.Value), len(tc.value))
+		}
+		var got string
+		if _, err := cd.Get(c, tc.key, &got); err != nil {
+			t.Fatalf("CompressedCodec.Get(%q): %v", tc.key, err)
+		}
+		if got != tc.value {
+			t.Errorf("%q: got %d bytes, want %d", tc.key, len(got), len(tc.value))
+		}
+	}
+
+	// Values written by the plain codec can be read.
+	if err := JSON.Set(c, &Item{Key: "plain", Object: large}); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if _, err := cd.Get(c, "plain", &got); err != nil || got != large {
+		t.Errorf("CompressedCodec.Get of a plain value: got %d bytes, %v", len(got), err)
+	}
+}
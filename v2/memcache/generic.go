@@ -0,0 +1,100 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package memcache
+
+import (
+	"context"
+
+	"google.golang.org/appengine/v2"
+)
+
+// GetAs gets the item for the given key and uses cd to decode its value into
+// a new value of type T. ErrCacheMiss is returned for a memcache cache miss.
+// The returned item can be passed to CompareAndSwap.
+// The key must be at most 250 bytes in length.
+func GetAs[T any](c context.Context, cd ValueCodec, key string) (T, *Item, error) {
+	var v T
+	i, err := cd.Get(c, key, &v)
+	if err != nil {
+		return v, nil, err
+	}
+	i.Object = v
+	return v, i, nil
+}
+
+// GetMultiAs is a batch version of GetAs. The returned map from keys to
+// values may have fewer elements than the input slice, due to memcache cache
+// misses. If any values cannot be decoded, they are omitted from the map and
+// an appengine.MultiError is returned with the decoding errors at the
+// corresponding key indexes.
+func GetMultiAs[T any](c context.Context, cd ValueCodec, key []string) (map[string]T, error) {
+	items, err := GetMulti(c, key)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]T, len(items))
+	var me appengine.MultiError
+	for i, k := range key {
+		item, ok := items[k]
+		if !ok {
+			continue
+		}
+		var v T
+		if err := cd.decode(item, &v); err != nil {
+			if me == nil {
+				me = make(appengine.MultiError, len(key))
+			}
+			me[i] = err
+			continue
+		}
+		m[k] = v
+	}
+	if me != nil {
+		return m, me
+	}
+	return m, nil
+}
+
+// Update atomically modifies the value stored under key. It gets and decodes
+// the current value with cd, calls f to modify it, and writes it back with
+// CompareAndSwap. If there is no current value, f is called with a pointer to
+// the zero value of T and the result is written with Add. If another client
+// modifies, adds or evicts the item in between, Update tries again, up to
+// attempts times in total; if attempts is not positive, it defaults to 3.
+// ErrCASConflict is returned if every attempt fails.
+//
+// If f returns an error, nothing is written and Update returns that error.
+// The value is written with no expiration time.
+func Update[T any](c context.Context, cd ValueCodec, key string, attempts int, f func(*T) error) error {
+	if attempts <= 0 {
+		attempts = 3
+	}
+	for i := 0; i < attempts; i++ {
+		v, item, err := GetAs[T](c, cd, key)
+		switch err {
+		case nil:
+		case ErrCacheMiss:
+			item = &Item{Key: key}
+		default:
+			return err
+		}
+		if err := f(&v); err != nil {
+			return err
+		}
+		item.Object = &v
+		if item.casID != 0 {
+			err = cd.CompareAndSwap(c, item)
+		} else {
+			err = cd.Add(c, item)
+		}
+		if err != ErrCASConflict && err != ErrNotStored {
+			return err
+		}
+	}
+	return ErrCASConflict
+}
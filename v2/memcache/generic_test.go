@@ -0,0 +1,113 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package memcache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/internal/aetesting"
+)
+
+type counter struct {
+	N    int
+	Name string
+}
+
+func TestGetAs(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	for _, cd := range []Codec{Gob, JSON} {
+		if err := cd.Set(c, &Item{Key: "k", Object: counter{3, "x"}}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		v, item, err := GetAs[counter](c, cd, "k")
+		if err != nil {
+			t.Fatalf("GetAs: %v", err)
+		}
+		if v != (counter{3, "x"}) || item.Key != "k" {
+			t.Errorf("GetAs = %+v, %q, want {3 x}, k", v, item.Key)
+		}
+		if _, _, err := GetAs[counter](c, cd, "missing"); err != ErrCacheMiss {
+			t.Errorf("GetAs(missing): got %v want %v", err, ErrCacheMiss)
+		}
+	}
+}
+
+func TestGetMultiAs(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	if err := JSON.Set(c, &Item{Key: "a", Object: counter{N: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(c, &Item{Key: "bad", Value: []byte("not json")}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := GetMultiAs[counter](c, JSON, []string{"a", "missing", "bad"})
+	me, ok := err.(appengine.MultiError)
+	if !ok || me[0] != nil || me[1] != nil || me[2] == nil {
+		t.Errorf("GetMultiAs: got error %v, want an error for key 2 only", err)
+	}
+	if len(m) != 1 || m["a"].N != 1 {
+		t.Errorf("GetMultiAs = %v, want map[a:{1 }]", m)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	inc := func(v *counter) error {
+		v.N++
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		if err := Update(c, Gob, "k", 0, inc); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	if v, _, err := GetAs[counter](c, Gob, "k"); err != nil || v.N != 3 {
+		t.Errorf("after 3 updates got %+v, %v want N=3", v, err)
+	}
+
+	// A concurrent modification on every attempt exhausts the attempts.
+	calls := 0
+	err := Update(c, Gob, "k", 2, func(v *counter) error {
+		calls++
+		return Gob.Set(c, &Item{Key: "k", Object: counter{N: 100}})
+	})
+	if err != ErrCASConflict || calls != 2 {
+		t.Errorf("conflicting Update: got %v after %d calls, want %v after 2", err, calls, ErrCASConflict)
+	}
+
+	errStop := errors.New("stop")
+	if err := Update(c, Gob, "k", 0, func(*counter) error { return errStop }); err != errStop {
+		t.Errorf("Update: got %v want %v", err, errStop)
+	}
+}
+
+func TestCompressedCodecGeneric(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	cd := CompressedCodec{Codec: JSON, Threshold: 1}
+	name := strings.Repeat("x", 100)
+	if err := Update(c, cd, "k", 0, func(v *counter) error {
+		v.N, v.Name = v.N+1, name
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	v, item, err := GetAs[counter](c, cd, "k")
+	if err != nil || v != (counter{1, name}) {
+		t.Fatalf("GetAs = %+v, %v, want N=1", v, err)
+	}
+	if item.Flags&CompressedFlag == 0 {
+		t.Errorf("item stored with flags %#x, want it compressed", item.Flags)
+	}
+	m, err := GetMultiAs[counter](c, cd, []string{"k"})
+	if err != nil || m["k"] != v {
+		t.Errorf("GetMultiAs = %v, %v, want map[k:%v]", m, err, v)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+/*
+Package mcstats gathers memcache statistics on the client, per namespace and
+key prefix.
+
+memcache.Stats reports the service's statistics for the whole application.
+A Recorder instead observes the memcache calls made with the contexts it
+returns, and attributes every item read or written to the namespace and
+prefix of its key, so that ineffective caches can be told apart.
+
+Example:
+
+	var recorder = mcstats.NewRecorder(mcstats.DelimitedPrefix(":"))
+
+	func handle(w http.ResponseWriter, r *http.Request) {
+		ctx := recorder.NewContext(appengine.NewContext(r))
+		item, err := memcache.Get(ctx, "user:"+id)
+		...
+	}
+
+	func statusHandler(w http.ResponseWriter, r *http.Request) {
+		for k, s := range recorder.Snapshot() {
+			fmt.Fprintf(w, "%q %q: hit ratio %.2f\n", k.Namespace, k.Prefix, s.HitRatio())
+		}
+	}
+
+The statistics are kept in the memory of the instance, so each instance of
+an application gathers its own.
+*/
+package mcstats // import "google.golang.org/appengine/v2/memcache/mcstats"
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/memcache"
+)
+
+// Key identifies the keys whose statistics are gathered together.
+type Key struct {
+	Namespace string
+	Prefix    string
+}
+
+// Stats are the statistics gathered for a Key.
+type Stats struct {
+	// Calls is the number of memcache calls that involved at least one key
+	// with this prefix, and Latency is their total duration. A batch call
+	// counts once for each prefix it involves.
+	Calls   int64
+	Latency time.Duration
+
+	// Hits and Misses count the keys found and not found by Get, GetMulti,
+	// Peek and increments of existing items.
+	Hits   int64
+	Misses int64
+	// Sets counts the items stored by Set, Add, CompareAndSwap and their
+	// batch variants, and SetFailures the items they did not store.
+	Sets        int64
+	SetFailures int64
+	// Deletes counts the items deleted, and Increments the items
+	// incremented or decremented.
+	Deletes    int64
+	Increments int64
+	// Errors counts the items involved in calls that failed.
+	Errors int64
+
+	// BytesRead and BytesWritten are the total sizes of the values read
+	// and written.
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// HitRatio returns the fraction of lookups that were hits, or zero if there
+// were none.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// MeanLatency returns the mean duration of the calls, or zero if there were
+// none.
+func (s Stats) MeanLatency() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Calls)
+}
+
+// DelimitedPrefix returns a prefix function that maps a key to the part of it
+// before the first occurrence of delim, or to the whole key if it does not
+// contain delim.
+func DelimitedPrefix(delim string) func(key string) string {
+	return func(key string) string {
+		if i := strings.Index(key, delim); i >= 0 {
+			return key[:i]
+		}
+		return key
+	}
+}
+
+// Recorder gathers statistics for the memcache calls made with the contexts
+// it returns. It is safe for concurrent use.
+type Recorder struct {
+	prefix func(key string) string
+
+	mu    sync.Mutex
+	stats map[Key]*Stats
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// NewRecorder returns a Recorder that gathers statistics per key prefix, as
+// returned by prefix. If prefix is nil, all the keys of a namespace are
+// gathered together under the empty prefix.
+func NewRecorder(prefix func(key string) string) *Recorder {
+	if prefix == nil {
+		prefix = func(string) string { return "" }
+	}
+	return &Recorder{
+		prefix: prefix,
+		stats:  make(map[Key]*Stats),
+		now:    time.Now,
+	}
+}
+
+// NewContext returns a context derived from c whose memcache calls are
+// recorded by r.
+func (r *Recorder) NewContext(c context.Context) context.Context {
+	return internal.WithCallOverride(c, r.call)
+}
+
+// Snapshot returns a copy of the statistics gathered so far.
+func (r *Recorder) Snapshot() map[Key]Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[Key]Stats, len(r.stats))
+	for k, s := range r.stats {
+		m[k] = *s
+	}
+	return m
+}
+
+// Reset discards the statistics gathered so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats = make(map[Key]*Stats)
+}
+
+// event is the outcome of a call for a single item.
+type event struct {
+	key   []byte
+	apply func(s *Stats)
+}
+
+func (r *Recorder) call(ctx context.Context, service, method string, in, out proto.Message) error {
+	if service != "memcache" {
+		return internal.Call(ctx, service, method, in, out)
+	}
+	start := r.now()
+	err := internal.Call(ctx, service, method, in, out)
+	latency := r.now().Sub(start)
+
+	ns, keys := requestKeys(in)
+	if len(keys) == 0 {
+		// FlushAll, Stats and GrabTail are not attributable to keys.
+		return err
+	}
+	var events []event
+	if err != nil {
+		for _, k := range keys {
+			events = append(events, event{k, func(s *Stats) { s.Errors++ }})
+		}
+	} else {
+		events = responseEvents(in, out)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	called := make(map[Key]bool)
+	for _, k := range keys {
+		key := r.key(ns, k)
+		if !called[key] {
+			called[key] = true
+			s := r.get(key)
+			s.Calls++
+			s.Latency += latency
+		}
+	}
+	for _, e := range events {
+		e.apply(r.get(r.key(ns, e.key)))
+	}
+	return err
+}
+
+func (r *Recorder) key(ns string, key []byte) Key {
+	return Key{Namespace: ns, Prefix: r.prefix(string(key))}
+}
+
+// get returns the statistics for k, creating them if necessary. r.mu must be
+// held.
+func (r *Recorder) get(k Key) *Stats {
+	s, ok := r.stats[k]
+	if !ok {
+		s = &Stats{}
+		r.stats[k] = s
+	}
+	return s
+}
+
+// requestKeys returns the namespace and keys of a memcache request.
+func requestKeys(in proto.Message) (ns string, keys [][]byte) {
+	switch req := in.(type) {
+	case *pb.MemcacheGetRequest:
+		return req.GetNameSpace(), req.Key
+	case *pb.MemcacheSetRequest:
+		for _, it := range req.Item {
+			keys = append(keys, it.Key)
+		}
+		return req.GetNameSpace(), keys
+	case *pb.MemcacheDeleteRequest:
+		for _, it := range req.Item {
+			keys = append(keys, it.Key)
+		}
+		return req.GetNameSpace(), keys
+	case *pb.MemcacheIncrementRequest:
+		return req.GetNameSpace(), [][]byte{req.Key}
+	case *pb.MemcacheBatchIncrementRequest:
+		for _, it := range req.Item {
+			keys = append(keys, it.Key)
+		}
+		return req.GetNameSpace(), keys
+	}
+	return "", nil
+}
+
+// responseEvents returns the outcome for each item of a successful call.
+func responseEvents(in, out proto.Message) []event {
+	var events []event
+	switch req := in.(type) {
+	case *pb.MemcacheGetRequest:
+		res := out.(*pb.MemcacheGetResponse)
+		found := make(map[string]bool)
+		for _, it := range res.Item {
+			n := int64(len(it.Value))
+			found[string(it.Key)] = true
+			events = append(events, event{it.Key, func(s *Stats) {
+				s.Hits++
+				s.BytesRead += n
+			}})
+		}
+		for _, k := range req.Key {
+			if !found[string(k)] {
+				events = append(events, event{k, func(s *Stats) { s.Misses++ }})
+			}
+		}
+	case *pb.MemcacheSetRequest:
+		res := out.(*pb.MemcacheSetResponse)
+		for i, it := range req.Item {
+			n := int64(len(it.Value))
+			stored := i < len(res.SetStatus) && res.SetStatus[i] == pb.MemcacheSetResponse_STORED
+			events = append(events, event{it.Key, func(s *Stats) {
+				if stored {
+					s.Sets++
+					s.BytesWritten += n
+				} else {
+					s.SetFailures++
+				}
+			}})
+		}
+	case *pb.MemcacheDeleteRequest:
+		res := out.(*pb.MemcacheDeleteResponse)
+		for i, it := range req.Item {
+			deleted := i < len(res.DeleteStatus) && res.DeleteStatus[i] == pb.MemcacheDeleteResponse_DELETED
+			events = append(events, event{it.Key, func(s *Stats) {
+				if deleted {
+					s.Deletes++
+				}
+			}})
+		}
+	case *pb.MemcacheIncrementRequest:
+		res := out.(*pb.MemcacheIncrementResponse)
+		events = append(events, incrEvent(req, res))
+	case *pb.MemcacheBatchIncrementRequest:
+		res := out.(*pb.MemcacheBatchIncrementResponse)
+		for i, it := range req.Item {
+			r := &pb.MemcacheIncrementResponse{}
+			if i < len(res.Item) {
+				r = res.Item[i]
+			}
+			events = append(events, incrEvent(it, r))
+		}
+	}
+	return events
+}
+
+// incrEvent returns the outcome of an increment. Increments that create the
+// item from an initial value are neither hits nor misses.
+func incrEvent(req *pb.MemcacheIncrementRequest, res *pb.MemcacheIncrementResponse) event {
+	ok := res.NewValue != nil &&
+		(res.IncrementStatus == nil || res.GetIncrementStatus() == pb.MemcacheIncrementResponse_OK)
+	existing := req.InitialValue == nil
+	return event{req.Key, func(s *Stats) {
+		switch {
+		case ok:
+			s.Increments++
+			if existing {
+				s.Hits++
+			}
+		case existing:
+			s.Misses++
+		}
+	}}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package mcstats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	"google.golang.org/appengine/v2/memcache"
+)
+
+func TestRecorder(t *testing.T) {
+	mc := aetesting.NewMemcache()
+	r := NewRecorder(DelimitedPrefix(":"))
+	now := time.Now()
+	r.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	c := r.NewContext(mc.Context())
+
+	if err := memcache.SetMulti(c, []*memcache.Item{
+		{Key: "user:1", Value: []byte("alice")},
+		{Key: "user:2", Value: []byte("bob")},
+		{Key: "page:/", Value: []byte("<html>")},
+	}); err != nil {
+		t.Fatalf("SetMulti: %v", err)
+	}
+	if err := memcache.Add(c, &memcache.Item{Key: "user:1", Value: []byte("x")}); err != memcache.ErrNotStored {
+		t.Fatalf("Add: got %v, want %v", err, memcache.ErrNotStored)
+	}
+	if _, err := memcache.GetMulti(c, []string{"user:1", "user:3", "page:/"}); err != nil {
+		t.Fatalf("GetMulti: %v", err)
+	}
+	if _, err := memcache.IncrementExisting(c, "count:a", 1); err != memcache.ErrCacheMiss {
+		t.Fatalf("IncrementExisting: got %v, want %v", err, memcache.ErrCacheMiss)
+	}
+	if _, err := memcache.Increment(c, "count:a", 1, 0); err != nil {
+		t.Fatalf("Increment: %v", err)
+	}
+	if err := memcache.Delete(c, "user:2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	nc, err := appengine.Namespace(c, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := memcache.Get(nc, "user:1"); err != memcache.ErrCacheMiss {
+		t.Fatalf("Get in namespace: got %v, want %v", err, memcache.ErrCacheMiss)
+	}
+	if _, err := memcache.Stats(c); err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+
+	want := map[Key]Stats{
+		{"", "user"}: {
+			Calls: 4, Latency: 4 * time.Millisecond,
+			Hits: 1, Misses: 1, Sets: 2, SetFailures: 1, Deletes: 1,
+			BytesRead: 5, BytesWritten: 8,
+		},
+		{"", "page"}: {
+			Calls: 2, Latency: 2 * time.Millisecond,
+			Hits: 1, Sets: 1, BytesRead: 6, BytesWritten: 6,
+		},
+		{"", "count"}: {
+			Calls: 2, Latency: 2 * time.Millisecond,
+			Misses: 1, Increments: 1,
+		},
+		{"other", "user"}: {
+			Calls: 1, Latency: time.Millisecond,
+			Misses: 1,
+		},
+	}
+	got := r.Snapshot()
+	if len(got) != len(want) {
+		t.Errorf("Snapshot has %d keys, want %d: %+v", len(got), len(want), got)
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("Snapshot[%+v]:\ngot  %+v\nwant %+v", k, got[k], w)
+		}
+	}
+	if s := got[Key{"", "user"}]; s.HitRatio() != 0.5 || s.MeanLatency() != time.Millisecond {
+		t.Errorf("user: HitRatio = %v, MeanLatency = %v; want 0.5, 1ms", s.HitRatio(), s.MeanLatency())
+	}
+
+	r.Reset()
+	if got := r.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot after Reset = %+v, want empty", got)
+	}
+}
+
+func TestRecorderErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	c := internal.WithCallOverride(context.Background(), func(ctx context.Context, service, method string, in, out proto.Message) error {
+		return errBoom
+	})
+	r := NewRecorder(nil)
+	c = r.NewContext(c)
+	if _, err := memcache.GetMulti(c, []string{"a", "b"}); err != errBoom {
+		t.Fatalf("GetMulti: got %v, want %v", err, errBoom)
+	}
+	want := Stats{Calls: 1, Errors: 2}
+	got := r.Snapshot()[Key{}]
+	got.Latency = 0
+	if got != want {
+		t.Errorf("Snapshot:\ngot  %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+/*
+Package mcsync provides coordination primitives built on memcache: a
+lease-based Mutex and a RateLimiter.
+
+Memcache is a cache, not a database. Any item may be evicted at any time,
+for example under memory pressure, and the service may be flushed or
+unavailable. The primitives in this package therefore give best-effort
+guarantees only:
+
+  - A Mutex whose item is evicted is silently released, so two clients may
+    both believe they hold it. Use a Mutex to avoid duplicated work, not to
+    protect data; use datastore transactions when correctness depends on
+    mutual exclusion.
+  - A RateLimiter whose counter is evicted forgets the events counted so
+    far in the current window, so it may allow more events than its limit.
+
+Both are safe for use by many instances of an application at once, as all
+the state is kept in memcache.
+*/
+package mcsync // import "google.golang.org/appengine/v2/memcache/mcsync"
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/appengine/v2/memcache"
+)
+
+var (
+	// ErrLocked is returned by TryLock when the mutex is held by another
+	// owner.
+	ErrLocked = errors.New("mcsync: mutex is locked")
+	// ErrNotLocked is returned by Renew and Unlock when the mutex is not
+	// held by the caller, because it was never locked, its lease expired,
+	// or its item was evicted.
+	ErrNotLocked = errors.New("mcsync: mutex is not locked by this owner")
+)
+
+// Mutex is a lease-based mutual exclusion lock stored in a memcache item.
+// Locking it adds the item with an expiration equal to the lease, so a lock
+// whose owner crashes is released when the lease runs out. An owner that
+// needs the lock for longer must call Renew before the lease expires.
+//
+// Each Mutex value is a distinct owner. A Mutex must not be copied after
+// first use.
+type Mutex struct {
+	key   string
+	lease time.Duration
+	token []byte
+}
+
+// NewMutex returns a Mutex for the given memcache key with the given lease
+// duration, which is rounded down to whole seconds and must be at least one
+// second; otherwise TryLock, Lock and Renew return an error.
+func NewMutex(key string, lease time.Duration) *Mutex {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("mcsync: cannot generate owner token: " + err.Error())
+	}
+	t := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(t, b)
+	return &Mutex{key: key, lease: lease, token: t}
+}
+
+// TryLock acquires the mutex if it is not held, returning ErrLocked if it
+// is.
+func (m *Mutex) TryLock(c context.Context) error {
+	if err := m.checkLease(); err != nil {
+		return err
+	}
+	err := memcache.Add(c, &memcache.Item{
+		Key:        m.key,
+		Value:      m.token,
+		Expiration: m.lease,
+	})
+	if err == memcache.ErrNotStored {
+		return ErrLocked
+	}
+	return err
+}
+
+// Lock acquires the mutex, polling with exponential backoff until it is
+// released or its lease expires. It returns the context's error if c is
+// done first.
+func (m *Mutex) Lock(c context.Context) error {
+	wait := 10 * time.Millisecond
+	for {
+		err := m.TryLock(c)
+		if err != ErrLocked {
+			return err
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-c.Done():
+			t.Stop()
+			return c.Err()
+		}
+		if wait *= 2; wait > time.Second {
+			wait = time.Second
+		}
+	}
+}
+
+// Renew extends the lease of a held mutex to the full lease duration from
+// now. The mutex item is replaced with CompareAndSwap, so Renew fails with
+// ErrNotLocked rather than taking over a lock acquired by another owner.
+func (m *Mutex) Renew(c context.Context) error {
+	if err := m.checkLease(); err != nil {
+		return err
+	}
+	return m.replace(c, m.lease)
+}
+
+// checkLease reports an error if the lease is too short: memcache would
+// store the item as already expired, so the mutex would not be held.
+func (m *Mutex) checkLease() error {
+	if m.lease < time.Second {
+		return fmt.Errorf("mcsync: mutex lease %v is shorter than a second", m.lease)
+	}
+	return nil
+}
+
+// Unlock releases a held mutex. Like Renew, it uses CompareAndSwap so that
+// it never releases a lock acquired by another owner after this owner's
+// lease expired; in that case it returns ErrNotLocked.
+func (m *Mutex) Unlock(c context.Context) error {
+	// An expiration of less than a second makes the item expire
+	// immediately.
+	return m.replace(c, time.Nanosecond)
+}
+
+// replace rewrites the mutex item with the given expiration, if it is
+// still held by m.
+func (m *Mutex) replace(c context.Context, expiration time.Duration) error {
+	item, err := memcache.Get(c, m.key)
+	if err == memcache.ErrCacheMiss {
+		return ErrNotLocked
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(item.Value, m.token) {
+		return ErrNotLocked
+	}
+	item.Expiration = expiration
+	switch err := memcache.CompareAndSwap(c, item); err {
+	case memcache.ErrCASConflict, memcache.ErrNotStored:
+		return ErrNotLocked
+	default:
+		return err
+	}
+}
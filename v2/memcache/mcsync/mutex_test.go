@@ -0,0 +1,84 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package mcsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/appengine/v2/internal/aetesting"
+)
+
+func TestMutex(t *testing.T) {
+	mc := aetesting.NewMemcache()
+	now := time.Now()
+	mc.Now = func() time.Time { return now }
+	c := mc.Context()
+
+	a, b := NewMutex("lock", 10*time.Second), NewMutex("lock", 10*time.Second)
+	if err := a.TryLock(c); err != nil {
+		t.Fatalf("a.TryLock: %v", err)
+	}
+	if err := b.TryLock(c); err != ErrLocked {
+		t.Errorf("b.TryLock while a holds the lock: got %v want %v", err, ErrLocked)
+	}
+	if err := b.Unlock(c); err != ErrNotLocked {
+		t.Errorf("b.Unlock while a holds the lock: got %v want %v", err, ErrNotLocked)
+	}
+
+	// Renewing pushes the expiry out by a full lease.
+	now = now.Add(8 * time.Second)
+	if err := a.Renew(c); err != nil {
+		t.Fatalf("a.Renew: %v", err)
+	}
+	now = now.Add(8 * time.Second)
+	if err := b.TryLock(c); err != ErrLocked {
+		t.Errorf("b.TryLock after renewal: got %v want %v", err, ErrLocked)
+	}
+
+	// Once the lease expires, another owner can take the lock and the
+	// previous owner can no longer renew or release it.
+	now = now.Add(3 * time.Second)
+	if err := b.TryLock(c); err != nil {
+		t.Fatalf("b.TryLock after expiry: %v", err)
+	}
+	if err := a.Renew(c); err != ErrNotLocked {
+		t.Errorf("a.Renew after losing the lock: got %v want %v", err, ErrNotLocked)
+	}
+	if err := a.Unlock(c); err != ErrNotLocked {
+		t.Errorf("a.Unlock after losing the lock: got %v want %v", err, ErrNotLocked)
+	}
+
+	if err := b.Unlock(c); err != nil {
+		t.Fatalf("b.Unlock: %v", err)
+	}
+	if err := a.TryLock(c); err != nil {
+		t.Errorf("a.TryLock after b.Unlock: %v", err)
+	}
+}
+
+func TestMutexLockCanceled(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	if err := NewMutex("lock", time.Minute).Lock(c); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	c, cancel := context.WithTimeout(c, 50*time.Millisecond)
+	defer cancel()
+	if err := NewMutex("lock", time.Minute).Lock(c); err != context.DeadlineExceeded {
+		t.Errorf("Lock of a held mutex: got %v want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestMutexShortLease(t *testing.T) {
+	c := aetesting.NewMemcache().Context()
+	m := NewMutex("lock", 500*time.Millisecond)
+	if err := m.TryLock(c); err == nil || err == ErrLocked {
+		t.Errorf("TryLock with a sub-second lease: got %v, want a lease error", err)
+	}
+	if err := m.Renew(c); err == nil || err == ErrNotLocked {
+		t.Errorf("Renew with a sub-second lease: got %v, want a lease error", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package mcsync
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/appengine/v2/memcache"
+)
+
+// MaxRateLimiterKeyLen is the maximum length of RateLimiter.Key. It leaves
+// room below the memcache key limit for the window suffix.
+const MaxRateLimiterKeyLen = 220
+
+// maxIncrAttempts is the number of times incr tries to increment or create a
+// counter before giving up.
+const maxIncrAttempts = 10
+
+// RateLimiter limits the rate of events, such as requests by a user, to
+// Limit events per Window. Events are counted with memcache.Increment in a
+// counter per window, whose key is derived from Key and the window's start
+// time.
+//
+// A fixed window limiter allows at most Limit events in each window, but may
+// allow up to twice that many in a period of length Window that straddles two
+// windows. A sliding window limiter avoids this by weighting the previous
+// window's count by the fraction of it that lies within the last Window,
+// at the cost of an extra memcache read per event. Events that are refused
+// are still counted.
+type RateLimiter struct {
+	// Key identifies the events being limited. It must be at most
+	// MaxRateLimiterKeyLen bytes in length.
+	Key string
+	// Limit is the number of events allowed per window.
+	Limit int64
+	// Window is the length of a window. It must be at least one second.
+	Window time.Duration
+	// Sliding selects a sliding window limiter instead of a fixed window
+	// limiter.
+	Sliding bool
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// Allow records an event and reports whether it is within the limit.
+func (r *RateLimiter) Allow(c context.Context) (bool, error) {
+	return r.AllowN(c, 1)
+}
+
+// AllowN records n events at once and reports whether they are within the
+// limit.
+func (r *RateLimiter) AllowN(c context.Context, n int64) (bool, error) {
+	if r.Window < time.Second {
+		return false, fmt.Errorf("mcsync: rate limiter window %v is shorter than a second", r.Window)
+	}
+	if len(r.Key) > MaxRateLimiterKeyLen {
+		return false, fmt.Errorf("mcsync: rate limiter key %q is longer than %d bytes", r.Key, MaxRateLimiterKeyLen)
+	}
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	t := now()
+	window := t.UnixNano() / int64(r.Window)
+	count, err := r.incr(c, r.windowKey(window), n)
+	if err != nil {
+		return false, err
+	}
+	if !r.Sliding {
+		return count <= uint64(r.Limit), nil
+	}
+
+	prev, err := memcache.Get(c, r.windowKey(window-1))
+	var prevCount uint64
+	switch err {
+	case nil:
+		if prevCount, err = strconv.ParseUint(string(prev.Value), 10, 64); err != nil {
+			return false, err
+		}
+	case memcache.ErrCacheMiss:
+	default:
+		return false, err
+	}
+	elapsed := float64(t.UnixNano()%int64(r.Window)) / float64(r.Window)
+	estimate := float64(prevCount)*(1-elapsed) + float64(count)
+	return estimate <= float64(r.Limit), nil
+}
+
+// windowKey returns the key of the counter for the given window.
+func (r *RateLimiter) windowKey(window int64) string {
+	return r.Key + ":" + strconv.FormatInt(window, 10)
+}
+
+// incr adds n to the counter with the given key, creating it with an
+// expiration if it does not exist, and returns the new count. It gives up
+// after maxIncrAttempts attempts, if the counter keeps disappearing between
+// a failed increment and a failed creation.
+func (r *RateLimiter) incr(c context.Context, key string, n int64) (uint64, error) {
+	// The counter must outlive its window so that a sliding window
+	// limiter can read it during the next window.
+	expiration := 2 * r.Window
+	for i := 0; i < maxIncrAttempts; i++ {
+		v, err := memcache.IncrementExisting(c, key, n)
+		if err != memcache.ErrCacheMiss {
+			return v, err
+		}
+		// Increment cannot set an expiration, so the counter is created
+		// with Add; if another client creates it first, try again.
+		err = memcache.Add(c, &memcache.Item{
+			Key:        key,
+			Value:      []byte(strconv.FormatInt(n, 10)),
+			Expiration: expiration,
+		})
+		if err == nil {
+			return uint64(n), nil
+		}
+		if err != memcache.ErrNotStored {
+			return 0, err
+		}
+	}
+	return 0, fmt.Errorf("mcsync: counter %q could not be updated in %d attempts", key, maxIncrAttempts)
+}
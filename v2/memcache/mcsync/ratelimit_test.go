@@ -0,0 +1,99 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package mcsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/memcache"
+)
+
+func TestRateLimiter(t *testing.T) {
+	mc := aetesting.NewMemcache()
+	start := time.Unix(1000*60, 0) // the start of a window
+	now := start
+	mc.Now = func() time.Time { return now }
+	c := mc.Context()
+
+	allow := func(r *RateLimiter, n int) (allowed int) {
+		for i := 0; i < n; i++ {
+			ok, err := r.Allow(c)
+			if err != nil {
+				t.Fatalf("Allow: %v", err)
+			}
+			if ok {
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	fixed := &RateLimiter{Key: "fixed", Limit: 3, Window: time.Minute, now: func() time.Time { return now }}
+	sliding := &RateLimiter{Key: "sliding", Limit: 3, Window: time.Minute, Sliding: true, now: func() time.Time { return now }}
+
+	now = start.Add(50 * time.Second)
+	if got := allow(fixed, 5); got != 3 {
+		t.Errorf("fixed: allowed %d of 5 events in the first window, want 3", got)
+	}
+	if got := allow(sliding, 3); got != 3 {
+		t.Errorf("sliding: allowed %d of 3 events in the first window, want 3", got)
+	}
+
+	// Early in the next window, the fixed limiter starts afresh while the
+	// sliding limiter still counts most of the previous window.
+	now = start.Add(70 * time.Second)
+	if got := allow(fixed, 3); got != 3 {
+		t.Errorf("fixed: allowed %d of 3 events in the second window, want 3", got)
+	}
+	if got := allow(sliding, 1); got != 0 {
+		t.Errorf("sliding: allowed %d events just after a full window, want 0", got)
+	}
+
+	// Two windows later, the first window's counter no longer counts.
+	now = start.Add(130 * time.Second)
+	if got := allow(sliding, 1); got != 1 {
+		t.Errorf("sliding: allowed %d events after two windows, want 1", got)
+	}
+
+	bad := &RateLimiter{Key: "bad", Limit: 1, Window: time.Millisecond}
+	if _, err := bad.Allow(c); err == nil {
+		t.Error("Allow with a sub-second window: got nil error")
+	}
+	long := &RateLimiter{Key: strings.Repeat("k", MaxRateLimiterKeyLen+1), Limit: 1, Window: time.Minute}
+	if _, err := long.Allow(c); err == nil {
+		t.Error("Allow with a key that is too long: got nil error")
+	}
+}
+
+func TestRateLimiterGivesUp(t *testing.T) {
+	// The counter is never found by Increment, and always exists for Add.
+	calls := 0
+	c := internal.WithCallOverride(aetesting.NewMemcache().Context(), func(ctx context.Context, service, method string, in, out proto.Message) error {
+		calls++
+		switch method {
+		case "Increment":
+			out.(*pb.MemcacheIncrementResponse).IncrementStatus = pb.MemcacheIncrementResponse_NOT_CHANGED.Enum()
+		case "Set":
+			out.(*pb.MemcacheSetResponse).SetStatus = []pb.MemcacheSetResponse_SetStatusCode{pb.MemcacheSetResponse_NOT_STORED}
+		default:
+			return internal.Call(ctx, service, method, in, out)
+		}
+		return nil
+	})
+	r := &RateLimiter{Key: "contended", Limit: 1, Window: time.Minute}
+	if _, err := r.Allow(c); err == nil {
+		t.Error("Allow with a counter that cannot be updated: got nil error")
+	}
+	if want := 2 * maxIncrAttempts; calls != want {
+		t.Errorf("made %d memcache calls, want %d", calls, want)
+	}
+}
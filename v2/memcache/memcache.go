@@ -209,6 +209,36 @@ func getMulti(c context.Context, key []string, forCas bool, forPeek bool) (map[s
 	return m, nil
 }
 
+// GrabTail removes up to n items from the least recently used end of the
+// cache in the current namespace and returns them, oldest first. The items
+// only have their Value and Flags set; the server does not return their
+// keys.
+//
+// GrabTail is intended for best-effort work queues, such as buffering writes
+// in a dedicated namespace and periodically draining them in batches. Items
+// may be evicted before they are grabbed, so it must not be used for data
+// that cannot be lost.
+func GrabTail(c context.Context, n int) ([]*Item, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	req := &pb.MemcacheGrabTailRequest{
+		ItemCount: proto.Int32(int32(n)),
+	}
+	res := &pb.MemcacheGrabTailResponse{}
+	if err := internal.Call(c, "memcache", "GrabTail", req, res); err != nil {
+		return nil, err
+	}
+	items := make([]*Item, len(res.Item))
+	for i, p := range res.Item {
+		items[i] = &Item{
+			Value: p.Value,
+			Flags: p.GetFlags(),
+		}
+	}
+	return items, nil
+}
+
 // Delete deletes the item for the given key.
 // ErrCacheMiss is returned if the specified item can not be found.
 // The key must be at most 250 bytes in length.
@@ -275,7 +305,25 @@ func IncrementExisting(c context.Context, key string, delta int64) (newValue uin
 	return incr(c, key, delta, nil)
 }
 
-func incr(c context.Context, key string, delta int64, initialValue *uint64) (newValue uint64, err error) {
+// IncrementMulti is a batch version of Increment. It increments the value
+// of each key[i] by delta[i] in a single call, using initialValue for any
+// key that does not already exist, and returns the new values in the same
+// order. key and delta must have the same length.
+// If any increments fail, an appengine.MultiError is returned and the
+// corresponding new values are zero.
+func IncrementMulti(c context.Context, key []string, delta []int64, initialValue uint64) (newValue []uint64, err error) {
+	return incrMulti(c, key, delta, &initialValue)
+}
+
+// IncrementExistingMulti is a batch version of IncrementExisting.
+// ErrCacheMiss is reported in the appengine.MultiError for any key that
+// can not be found.
+func IncrementExistingMulti(c context.Context, key []string, delta []int64) (newValue []uint64, err error) {
+	return incrMulti(c, key, delta, nil)
+}
+
+// incrRequest returns the request to increment key by delta.
+func incrRequest(key string, delta int64, initialValue *uint64) *pb.MemcacheIncrementRequest {
 	req := &pb.MemcacheIncrementRequest{
 		Key:          []byte(key),
 		InitialValue: initialValue,
@@ -286,6 +334,51 @@ func incr(c context.Context, key string, delta int64, initialValue *uint64) (new
 		req.Delta = proto.Uint64(uint64(-delta))
 		req.Direction = pb.MemcacheIncrementRequest_DECREMENT.Enum()
 	}
+	return req
+}
+
+func incrMulti(c context.Context, key []string, delta []int64, initialValue *uint64) ([]uint64, error) {
+	if len(key) != len(delta) {
+		return nil, errors.New("memcache: key and delta have different lengths")
+	}
+	if len(key) == 0 {
+		return nil, nil
+	}
+	req := &pb.MemcacheBatchIncrementRequest{
+		Item: make([]*pb.MemcacheIncrementRequest, len(key)),
+	}
+	for i, k := range key {
+		req.Item[i] = incrRequest(k, delta[i], initialValue)
+	}
+	res := &pb.MemcacheBatchIncrementResponse{}
+	if err := internal.Call(c, "memcache", "BatchIncrement", req, res); err != nil {
+		return nil, err
+	}
+	if len(res.Item) != len(key) {
+		return nil, ErrServerError
+	}
+	newValue := make([]uint64, len(key))
+	me, any := make(appengine.MultiError, len(key)), false
+	for i, r := range res.Item {
+		switch r.GetIncrementStatus() {
+		case pb.MemcacheIncrementResponse_OK:
+			newValue[i] = r.GetNewValue()
+		case pb.MemcacheIncrementResponse_NOT_CHANGED:
+			me[i] = ErrCacheMiss
+			any = true
+		default:
+			me[i] = ErrServerError
+			any = true
+		}
+	}
+	if any {
+		return newValue, me
+	}
+	return newValue, nil
+}
+
+func incr(c context.Context, key string, delta int64, initialValue *uint64) (newValue uint64, err error) {
+	req := incrRequest(key, delta, initialValue)
 	res := &pb.MemcacheIncrementResponse{}
 	err = internal.Call(c, "memcache", "Increment", req, res)
 	if err != nil {
@@ -430,21 +523,54 @@ type Codec struct {
 // ErrCacheMiss is returned for a memcache cache miss.
 // The key must be at most 250 bytes in length.
 func (cd Codec) Get(c context.Context, key string, v interface{}) (*Item, error) {
+	return codecGet(c, cd, key, v)
+}
+
+func (cd Codec) decode(item *Item, v interface{}) error {
+	return cd.Unmarshal(item.Value, v)
+}
+
+func (cd Codec) encode(item *Item) (*Item, []byte, error) {
+	v, err := cd.Marshal(item.Object)
+	return item, v, err
+}
+
+func (cd Codec) set(c context.Context, items []*Item, policy pb.MemcacheSetRequest_SetPolicy) error {
+	return codecSet(c, cd, items, policy)
+}
+
+// ValueCodec is implemented by the codecs of this package, Codec and
+// CompressedCodec, and is accepted by the functions that decode values into
+// typed variables.
+type ValueCodec interface {
+	Get(c context.Context, key string, v interface{}) (*Item, error)
+	Add(c context.Context, item *Item) error
+	CompareAndSwap(c context.Context, item *Item) error
+
+	// decode decodes the value of an item returned by memcache into v.
+	decode(item *Item, v interface{}) error
+	// encode returns the item to store for item, which may be a modified
+	// copy of it, and its encoded value.
+	encode(item *Item) (*Item, []byte, error)
+}
+
+func codecGet(c context.Context, cd ValueCodec, key string, v interface{}) (*Item, error) {
 	i, err := Get(c, key)
 	if err != nil {
 		return nil, err
 	}
-	if err := cd.Unmarshal(i.Value, v); err != nil {
+	if err := cd.decode(i, v); err != nil {
 		return nil, err
 	}
 	return i, nil
 }
 
-func (cd Codec) set(c context.Context, items []*Item, policy pb.MemcacheSetRequest_SetPolicy) error {
+func codecSet(c context.Context, cd ValueCodec, items []*Item, policy pb.MemcacheSetRequest_SetPolicy) error {
 	var vs [][]byte
 	var me appengine.MultiError
+	encoded := make([]*Item, len(items))
 	for i, item := range items {
-		v, err := cd.Marshal(item.Object)
+		e, v, err := cd.encode(item)
 		if err != nil {
 			if me == nil {
 				me = make(appengine.MultiError, len(items))
@@ -452,15 +578,14 @@ func (cd Codec) set(c context.Context, items []*Item, policy pb.MemcacheSetReque
 			me[i] = err
 			continue
 		}
-		if me == nil {
-			vs = append(vs, v)
-		}
+		encoded[i] = e
+		vs = append(vs, v)
 	}
 	if me != nil {
 		return me
 	}
 
-	return set(c, items, vs, policy)
+	return set(c, encoded, vs, policy)
 }
 
 // Set writes the given item, unconditionally.
@@ -575,6 +700,14 @@ func namespaceMod(m proto.Message, namespace string) {
 		if m.NameSpace == nil {
 			m.NameSpace = &namespace
 		}
+	case *pb.MemcacheBatchIncrementRequest:
+		if m.NameSpace == nil {
+			m.NameSpace = &namespace
+		}
+	case *pb.MemcacheGrabTailRequest:
+		if m.NameSpace == nil {
+			m.NameSpace = &namespace
+		}
 	case *pb.MemcacheSetRequest:
 		if m.NameSpace == nil {
 			m.NameSpace = &namespace
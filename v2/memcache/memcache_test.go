@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+
 	"google.golang.org/appengine/v2"
 	"google.golang.org/appengine/v2/internal/aetesting"
 	pb "google.golang.org/appengine/v2/internal/memcache"
@@ -310,3 +312,83 @@ func TestPeekRequest(t *testing.T) {
 		t.Errorf("got %d, want %d", item.Timestamps.LastAccess.Unix(), t2)
 	}
 }
+
+func TestIncrementMulti(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "memcache", "BatchIncrement", func(req *pb.MemcacheBatchIncrementRequest, res *pb.MemcacheBatchIncrementResponse) error {
+		if n := len(req.Item); n != 3 {
+			t.Errorf("got %d items want 3", n)
+			return nil
+		}
+		if d := req.Item[1]; d.GetDirection() != pb.MemcacheIncrementRequest_DECREMENT || d.GetDelta() != 2 {
+			t.Errorf("item 1: got direction %v delta %d, want DECREMENT 2", d.GetDirection(), d.GetDelta())
+		}
+		for _, it := range req.Item {
+			if it.GetInitialValue() != 10 {
+				t.Errorf("item %q: got initial value %d want 10", it.Key, it.GetInitialValue())
+			}
+		}
+		res.Item = []*pb.MemcacheIncrementResponse{
+			{NewValue: proto.Uint64(11), IncrementStatus: pb.MemcacheIncrementResponse_OK.Enum()},
+			{NewValue: proto.Uint64(8), IncrementStatus: pb.MemcacheIncrementResponse_OK.Enum()},
+			{IncrementStatus: pb.MemcacheIncrementResponse_ERROR.Enum()},
+		}
+		return nil
+	})
+	vals, err := IncrementMulti(c, []string{"a", "b", "c"}, []int64{1, -2, 3}, 10)
+	me, ok := err.(appengine.MultiError)
+	if !ok {
+		t.Fatalf("got error %v want appengine.MultiError", err)
+	}
+	if me[0] != nil || me[1] != nil || me[2] != ErrServerError {
+		t.Errorf("got %v want [nil nil %v]", me, ErrServerError)
+	}
+	if len(vals) != 3 || vals[0] != 11 || vals[1] != 8 || vals[2] != 0 {
+		t.Errorf("got %v want [11 8 0]", vals)
+	}
+
+	if _, err := IncrementMulti(c, []string{"a"}, nil, 0); err == nil {
+		t.Error("got nil error for mismatched key and delta lengths")
+	}
+}
+
+func TestGrabTail(t *testing.T) {
+	m := aetesting.NewMemcache()
+	c, err := appengine.Namespace(m.Context(), "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"a", "b", "c"} {
+		if err := Set(c, &Item{Key: "k" + v, Value: []byte(v), Flags: 7}); err != nil {
+			t.Fatalf("Set(%q): %v", v, err)
+		}
+	}
+	// Touching "ka" moves it to the head of the LRU list.
+	if _, err := Get(c, "ka"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	// Items in other namespaces are not grabbed.
+	if err := Set(m.Context(), &Item{Key: "other", Value: []byte("x")}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	items, err := GrabTail(c, 2)
+	if err != nil {
+		t.Fatalf("GrabTail: %v", err)
+	}
+	var got []string
+	for _, it := range items {
+		if it.Flags != 7 {
+			t.Errorf("item %q: got flags %d want 7", it.Value, it.Flags)
+		}
+		got = append(got, string(it.Value))
+	}
+	if fmt.Sprint(got) != "[b c]" {
+		t.Errorf("GrabTail(2) = %v, want [b c]", got)
+	}
+	if _, err := Get(c, "kb"); err != ErrCacheMiss {
+		t.Errorf("Get of grabbed item: got %v want %v", err, ErrCacheMiss)
+	}
+	if items, err = GrabTail(c, 5); err != nil || len(items) != 1 || string(items[0].Value) != "a" {
+		t.Errorf("GrabTail(5) = %v, %v, want [a]", items, err)
+	}
+}
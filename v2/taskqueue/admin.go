@@ -0,0 +1,354 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+// ErrNoSuchTask is the error returned by FetchTask and ForceRun when the
+// queue has no task with the given name.
+var ErrNoSuchTask = errors.New("taskqueue: no such task")
+
+var noSuchTaskErrors = map[pb.TaskQueueServiceError_ErrorCode]bool{
+	pb.TaskQueueServiceError_UNKNOWN_TASK:    true,
+	pb.TaskQueueServiceError_TOMBSTONED_TASK: true,
+}
+
+func noSuchTask(err error) error {
+	if apiErr, ok := err.(*internal.APIError); ok && apiErr.Service == "taskqueue" && noSuchTaskErrors[pb.TaskQueueServiceError_ErrorCode(apiErr.Code)] {
+		return ErrNoSuchTask
+	}
+	return err
+}
+
+// defaultQueryLimit is the number of tasks returned by QueryTasks if the
+// query has no limit.
+const defaultQueryLimit = 100
+
+// TaskInfo describes a task in a queue, as returned by QueryTasks and
+// FetchTask. The embedded Task may be passed to Delete, ForceRun and, for
+// pull tasks, ModifyLease.
+type TaskInfo struct {
+	Task
+
+	// Created is when the task was added.
+	Created time.Time
+	// FirstTry is when the task was first dispatched or leased. It is zero
+	// if the task has never run.
+	FirstTry time.Time
+	// ExecutionCount is the number of times the task has been executed.
+	ExecutionCount int32
+	// LastRun describes the last attempt to run a push task. It is nil if
+	// the task has never run.
+	LastRun *TaskRun
+}
+
+// TaskRun describes an attempt to run a push task.
+type TaskRun struct {
+	Dispatched   time.Time     // when the task was dispatched
+	Lag          time.Duration // delay between the task's ETA and its dispatch
+	Elapsed      time.Duration // time taken by the task's request
+	ResponseCode int           // HTTP status of the response; zero if unknown
+	RetryReason  string
+}
+
+// TaskQuery selects tasks for QueryTasks. Tasks are listed in order of ETA,
+// then name. The zero value lists the first tasks of a queue.
+type TaskQuery struct {
+	// StartETA and StartName, if set, are the ETA and name of the first
+	// task to list. To list a queue page by page, set them to those of the
+	// task following the last one listed.
+	StartETA  time.Time
+	StartName string
+
+	// StartTag, if set, lists pull tasks from those with this tag.
+	StartTag string
+
+	// Limit is the maximum number of tasks to list. If zero, at most 100
+	// tasks are listed.
+	Limit int
+}
+
+// QueryTasks lists tasks in a named queue, without leasing or modifying
+// them. An empty queue name means that the default queue will be used. A
+// nil query lists the first tasks of the queue.
+func QueryTasks(c context.Context, queueName string, q *TaskQuery) ([]*TaskInfo, error) {
+	if queueName == "" {
+		queueName = "default"
+	}
+	if q == nil {
+		q = &TaskQuery{}
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	req := &pb.TaskQueueQueryTasksRequest{
+		QueueName: []byte(queueName),
+		MaxRows:   proto.Int32(int32(limit)),
+	}
+	if q.StartName != "" {
+		req.StartTaskName = []byte(q.StartName)
+	}
+	if !q.StartETA.IsZero() {
+		req.StartEtaUsec = proto.Int64(q.StartETA.UnixNano() / 1e3)
+	}
+	if q.StartTag != "" {
+		req.StartTag = []byte(q.StartTag)
+	}
+	res := &pb.TaskQueueQueryTasksResponse{}
+	if err := internal.Call(c, "taskqueue", "QueryTasks", req, res); err != nil {
+		return nil, err
+	}
+	tasks := make([]*TaskInfo, len(res.Task))
+	for i, t := range res.Task {
+		tasks[i] = taskInfoFromProto(t)
+	}
+	return tasks, nil
+}
+
+// FetchTask returns the task with the given name in a named queue.
+// An empty queue name means that the default queue will be used.
+// FetchTask returns ErrNoSuchTask if the queue has no such task.
+func FetchTask(c context.Context, name, queueName string) (*TaskInfo, error) {
+	if queueName == "" {
+		queueName = "default"
+	}
+	req := &pb.TaskQueueFetchTaskRequest{
+		QueueName: []byte(queueName),
+		TaskName:  []byte(name),
+	}
+	res := &pb.TaskQueueFetchTaskResponse{}
+	if err := internal.Call(c, "taskqueue", "FetchTask", req, res); err != nil {
+		return nil, noSuchTask(err)
+	}
+	if res.Task == nil || len(res.Task.Task) == 0 {
+		return nil, ErrNoSuchTask
+	}
+	return taskInfoFromProto(res.Task.Task[0]), nil
+}
+
+func usecToTime(usec int64) time.Time {
+	if usec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, usec*1e3)
+}
+
+func taskInfoFromProto(t *pb.TaskQueueQueryTasksResponse_Task) *TaskInfo {
+	ti := &TaskInfo{
+		Task: Task{
+			Path:       string(t.Url),
+			Payload:    t.Body,
+			Name:       string(t.TaskName),
+			Method:     "PULL",
+			ETA:        usecToTime(t.GetEtaUsec()),
+			RetryCount: t.GetRetryCount(),
+			Tag:        string(t.Tag),
+		},
+		Created:        usecToTime(t.GetCreationTimeUsec()),
+		FirstTry:       usecToTime(t.GetFirstTryUsec()),
+		ExecutionCount: t.GetExecutionCount(),
+	}
+	if t.Method != nil {
+		ti.Method = t.Method.String()
+	}
+	if len(t.Header) > 0 {
+		ti.Header = make(http.Header)
+		for _, h := range t.Header {
+			ti.Header.Add(string(h.Key), string(h.Value))
+		}
+	}
+	if t.RetryParameters != nil {
+		ti.RetryOptions = retryOptionsFromProto(t.RetryParameters)
+	}
+	if rl := t.Runlog; rl != nil {
+		ti.LastRun = &TaskRun{
+			Dispatched:   usecToTime(rl.GetDispatchedUsec()),
+			Lag:          time.Duration(rl.GetLagUsec()) * time.Microsecond,
+			Elapsed:      time.Duration(rl.GetElapsedUsec()) * time.Microsecond,
+			ResponseCode: int(rl.GetResponseCode()),
+			RetryReason:  rl.GetRetryReason(),
+		}
+	}
+	return ti
+}
+
+// retryOptionsFromProto converts pb.TaskQueueRetryParameters to RetryOptions.
+func retryOptionsFromProto(p *pb.TaskQueueRetryParameters) *RetryOptions {
+	opt := &RetryOptions{
+		RetryLimit: p.GetRetryLimit(),
+		AgeLimit:   time.Duration(p.GetAgeLimitSec()) * time.Second,
+	}
+	if p.MinBackoffSec != nil {
+		opt.MinBackoff = time.Duration(*p.MinBackoffSec * float64(time.Second))
+	}
+	if p.MaxBackoffSec != nil {
+		opt.MaxBackoff = time.Duration(*p.MaxBackoffSec * float64(time.Second))
+	}
+	if p.MaxDoublings != nil {
+		opt.MaxDoublings = *p.MaxDoublings
+		opt.ApplyZeroMaxDoublings = *p.MaxDoublings == 0
+	}
+	return opt
+}
+
+// ForceRun runs a push task in a named queue now, regardless of its ETA.
+// An empty queue name means that the default queue will be used.
+// ForceRun returns ErrNoSuchTask if the queue has no task with the task's
+// name.
+func ForceRun(c context.Context, task *Task, queueName string) error {
+	if queueName == "" {
+		queueName = "default"
+	}
+	req := &pb.TaskQueueForceRunRequest{
+		QueueName: []byte(queueName),
+		TaskName:  []byte(task.Name),
+	}
+	res := &pb.TaskQueueForceRunResponse{}
+	if err := internal.Call(c, "taskqueue", "ForceRun", req, res); err != nil {
+		return noSuchTask(err)
+	}
+	if ec := res.GetResult(); ec != pb.TaskQueueServiceError_OK {
+		return noSuchTask(&internal.APIError{
+			Service: "taskqueue",
+			Code:    int32(ec),
+		})
+	}
+	return nil
+}
+
+func pauseQueue(c context.Context, queueName string, pause bool) error {
+	if queueName == "" {
+		queueName = "default"
+	}
+	req := &pb.TaskQueuePauseQueueRequest{
+		AppId:     []byte(internal.FullyQualifiedAppID(c)),
+		QueueName: []byte(queueName),
+		Pause:     proto.Bool(pause),
+	}
+	res := &pb.TaskQueuePauseQueueResponse{}
+	return internal.Call(c, "taskqueue", "PauseQueue", req, res)
+}
+
+// PauseQueue pauses a queue: its tasks are kept, but not run or leased until
+// ResumeQueue is called.
+// An empty queue name means that the default queue will be used.
+func PauseQueue(c context.Context, queueName string) error {
+	return pauseQueue(c, queueName, true)
+}
+
+// ResumeQueue resumes a queue paused by PauseQueue.
+// An empty queue name means that the default queue will be used.
+func ResumeQueue(c context.Context, queueName string) error {
+	return pauseQueue(c, queueName, false)
+}
+
+// DeleteQueue deletes a queue and all its tasks. A queue that is still
+// defined in queue.yaml is recreated, empty, when queue.yaml is next
+// deployed.
+func DeleteQueue(c context.Context, queueName string) error {
+	if queueName == "" {
+		queueName = "default"
+	}
+	req := &pb.TaskQueueDeleteQueueRequest{
+		AppId:     []byte(internal.FullyQualifiedAppID(c)),
+		QueueName: []byte(queueName),
+	}
+	res := &pb.TaskQueueDeleteQueueResponse{}
+	return internal.Call(c, "taskqueue", "DeleteQueue", req, res)
+}
+
+// Queue is the configuration of a queue.
+type Queue struct {
+	Name string
+
+	// Rate is the rate at which tasks are run, in tasks per second, and
+	// BucketSize the number of tasks that may run in a burst. UserRate is
+	// the rate as written in queue.yaml, such as "5/s"; it is informative
+	// only.
+	Rate       float64
+	BucketSize int
+	UserRate   string
+
+	// MaxConcurrentRequests is the maximum number of tasks that may run at
+	// the same time. If zero, there is no limit.
+	MaxConcurrentRequests int
+
+	// Pull reports whether the queue is a pull queue.
+	Pull bool
+
+	// Paused reports whether the queue is paused. It is ignored by
+	// UpdateQueue; use PauseQueue and ResumeQueue instead.
+	Paused bool
+
+	// Retry options of the queue's tasks. May be nil.
+	RetryOptions *RetryOptions
+}
+
+// Queues returns the configuration of at most maxQueues of the application's
+// queues.
+func Queues(c context.Context, maxQueues int) ([]*Queue, error) {
+	req := &pb.TaskQueueFetchQueuesRequest{
+		MaxRows: proto.Int32(int32(maxQueues)),
+	}
+	res := &pb.TaskQueueFetchQueuesResponse{}
+	if err := internal.Call(c, "taskqueue", "FetchQueues", req, res); err != nil {
+		return nil, err
+	}
+	qs := make([]*Queue, len(res.Queue))
+	for i, q := range res.Queue {
+		qs[i] = &Queue{
+			Name:                  string(q.QueueName),
+			Rate:                  q.GetBucketRefillPerSecond(),
+			BucketSize:            int(q.GetBucketCapacity()),
+			UserRate:              q.GetUserSpecifiedRate(),
+			MaxConcurrentRequests: int(q.GetMaxConcurrentRequests()),
+			Pull:                  q.GetMode() == pb.TaskQueueMode_PULL,
+			Paused:                q.GetPaused(),
+		}
+		if q.RetryParameters != nil {
+			qs[i].RetryOptions = retryOptionsFromProto(q.RetryParameters)
+		}
+	}
+	return qs, nil
+}
+
+// UpdateQueue creates or updates a queue with the given configuration. The
+// changes last until queue.yaml is next deployed.
+func UpdateQueue(c context.Context, q *Queue) error {
+	name := q.Name
+	if name == "" {
+		name = "default"
+	}
+	req := &pb.TaskQueueUpdateQueueRequest{
+		QueueName:             []byte(name),
+		BucketRefillPerSecond: proto.Float64(q.Rate),
+		BucketCapacity:        proto.Int32(int32(q.BucketSize)),
+	}
+	if q.UserRate != "" {
+		req.UserSpecifiedRate = proto.String(q.UserRate)
+	}
+	if q.MaxConcurrentRequests > 0 {
+		req.MaxConcurrentRequests = proto.Int32(int32(q.MaxConcurrentRequests))
+	}
+	if q.Pull {
+		req.Mode = pb.TaskQueueMode_PULL.Enum()
+	}
+	if q.RetryOptions != nil {
+		req.RetryParameters = q.RetryOptions.toRetryParameters()
+	}
+	res := &pb.TaskQueueUpdateQueueResponse{}
+	return internal.Call(c, "taskqueue", "UpdateQueue", req, res)
+}
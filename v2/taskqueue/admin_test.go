@@ -0,0 +1,274 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	"google.golang.org/appengine/v2/internal/aetesting"
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+func TestQueryTasks(t *testing.T) {
+	eta := time.Unix(1500000000, 0)
+	c := aetesting.FakeSingleContext(t, "taskqueue", "QueryTasks", func(req *pb.TaskQueueQueryTasksRequest, res *pb.TaskQueueQueryTasksResponse) error {
+		if got, want := string(req.QueueName), "q"; got != want {
+			return fmt.Errorf("QueueName = %q, want %q", got, want)
+		}
+		if got, want := req.GetMaxRows(), int32(defaultQueryLimit); got != want {
+			return fmt.Errorf("MaxRows = %d, want %d", got, want)
+		}
+		if got, want := string(req.StartTaskName), "t0"; got != want {
+			return fmt.Errorf("StartTaskName = %q, want %q", got, want)
+		}
+		if got, want := req.GetStartEtaUsec(), eta.UnixNano()/1e3; got != want {
+			return fmt.Errorf("StartEtaUsec = %d, want %d", got, want)
+		}
+		res.Task = []*pb.TaskQueueQueryTasksResponse_Task{
+			{
+				TaskName:         []byte("t1"),
+				EtaUsec:          proto.Int64(eta.UnixNano() / 1e3),
+				Url:              []byte("/work"),
+				Method:           pb.TaskQueueQueryTasksResponse_Task_PUT.Enum(),
+				RetryCount:       proto.Int32(2),
+				Header:           []*pb.TaskQueueQueryTasksResponse_Task_Header{{Key: []byte("X-Foo"), Value: []byte("bar")}},
+				Body:             []byte("body"),
+				CreationTimeUsec: proto.Int64(eta.UnixNano()/1e3 - 1e6),
+				FirstTryUsec:     proto.Int64(eta.UnixNano()/1e3 + 1e6),
+				ExecutionCount:   proto.Int32(3),
+				RetryParameters:  &pb.TaskQueueRetryParameters{RetryLimit: proto.Int32(5), MaxDoublings: proto.Int32(0)},
+				Runlog: &pb.TaskQueueQueryTasksResponse_Task_RunLog{
+					DispatchedUsec: proto.Int64(eta.UnixNano()/1e3 + 2e6),
+					LagUsec:        proto.Int64(1500),
+					ElapsedUsec:    proto.Int64(2e6),
+					ResponseCode:   proto.Int64(500),
+				},
+			},
+			{
+				TaskName:         []byte("t2"),
+				EtaUsec:          proto.Int64(eta.UnixNano() / 1e3),
+				Body:             []byte("pull"),
+				CreationTimeUsec: proto.Int64(eta.UnixNano() / 1e3),
+				Tag:              []byte("tag"),
+			},
+		}
+		return nil
+	})
+	tasks, err := QueryTasks(c, "q", &TaskQuery{StartETA: eta, StartName: "t0"})
+	if err != nil {
+		t.Fatalf("QueryTasks: %v", err)
+	}
+	want := []*TaskInfo{
+		{
+			Task: Task{
+				Path:         "/work",
+				Payload:      []byte("body"),
+				Header:       http.Header{"X-Foo": {"bar"}},
+				Method:       "PUT",
+				Name:         "t1",
+				ETA:          eta,
+				RetryCount:   2,
+				RetryOptions: &RetryOptions{RetryLimit: 5, ApplyZeroMaxDoublings: true},
+			},
+			Created:        eta.Add(-time.Second),
+			FirstTry:       eta.Add(time.Second),
+			ExecutionCount: 3,
+			LastRun: &TaskRun{
+				Dispatched:   eta.Add(2 * time.Second),
+				Lag:          1500 * time.Microsecond,
+				Elapsed:      2 * time.Second,
+				ResponseCode: 500,
+			},
+		},
+		{
+			Task: Task{
+				Payload: []byte("pull"),
+				Method:  "PULL",
+				Name:    "t2",
+				ETA:     eta,
+				Tag:     "tag",
+			},
+			Created: eta,
+		},
+	}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("QueryTasks:\ngot  %+v\nwant %+v", tasks, want)
+	}
+}
+
+func TestFetchTask(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "taskqueue", "FetchTask", func(req *pb.TaskQueueFetchTaskRequest, res *pb.TaskQueueFetchTaskResponse) error {
+		if string(req.TaskName) != "exists" {
+			return &internal.APIError{Service: "taskqueue", Code: int32(pb.TaskQueueServiceError_UNKNOWN_TASK)}
+		}
+		res.Task = &pb.TaskQueueQueryTasksResponse{
+			Task: []*pb.TaskQueueQueryTasksResponse_Task{{
+				TaskName:         req.TaskName,
+				EtaUsec:          proto.Int64(1),
+				CreationTimeUsec: proto.Int64(1),
+			}},
+		}
+		return nil
+	})
+	task, err := FetchTask(c, "exists", "")
+	if err != nil {
+		t.Fatalf("FetchTask: %v", err)
+	}
+	if task.Name != "exists" {
+		t.Errorf("FetchTask returned task %q, want %q", task.Name, "exists")
+	}
+	if _, err := FetchTask(c, "missing", ""); err != ErrNoSuchTask {
+		t.Errorf("FetchTask of a missing task: got %v, want %v", err, ErrNoSuchTask)
+	}
+}
+
+func TestForceRun(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "taskqueue", "ForceRun", func(req *pb.TaskQueueForceRunRequest, res *pb.TaskQueueForceRunResponse) error {
+		switch string(req.TaskName) {
+		case "ok":
+			res.Result = pb.TaskQueueServiceError_OK.Enum()
+		case "gone":
+			res.Result = pb.TaskQueueServiceError_TOMBSTONED_TASK.Enum()
+		default:
+			res.Result = pb.TaskQueueServiceError_INTERNAL_ERROR.Enum()
+		}
+		return nil
+	})
+	if err := ForceRun(c, &Task{Name: "ok"}, ""); err != nil {
+		t.Errorf("ForceRun: %v", err)
+	}
+	if err := ForceRun(c, &Task{Name: "gone"}, ""); err != ErrNoSuchTask {
+		t.Errorf("ForceRun of a deleted task: got %v, want %v", err, ErrNoSuchTask)
+	}
+	want := &internal.APIError{Service: "taskqueue", Code: int32(pb.TaskQueueServiceError_INTERNAL_ERROR)}
+	if err := ForceRun(c, &Task{Name: "fail"}, ""); !reflect.DeepEqual(err, want) {
+		t.Errorf("ForceRun with an internal error: got %v, want %v", err, want)
+	}
+}
+
+func TestPauseQueue(t *testing.T) {
+	var paused []bool
+	c := aetesting.FakeSingleContext(t, "taskqueue", "PauseQueue", func(req *pb.TaskQueuePauseQueueRequest, res *pb.TaskQueuePauseQueueResponse) error {
+		if got, want := string(req.AppId), "s~app"; got != want {
+			return fmt.Errorf("AppId = %q, want %q", got, want)
+		}
+		if got, want := string(req.QueueName), "default"; got != want {
+			return fmt.Errorf("QueueName = %q, want %q", got, want)
+		}
+		paused = append(paused, req.GetPause())
+		return nil
+	})
+	c = internal.WithAppIDOverride(c, "s~app")
+	if err := PauseQueue(c, ""); err != nil {
+		t.Fatalf("PauseQueue: %v", err)
+	}
+	if err := ResumeQueue(c, ""); err != nil {
+		t.Fatalf("ResumeQueue: %v", err)
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(paused, want) {
+		t.Errorf("Pause requests = %v, want %v", paused, want)
+	}
+}
+
+func TestQueues(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "taskqueue", "FetchQueues", func(req *pb.TaskQueueFetchQueuesRequest, res *pb.TaskQueueFetchQueuesResponse) error {
+		if got := req.GetMaxRows(); got != 10 {
+			return fmt.Errorf("MaxRows = %d, want 10", got)
+		}
+		res.Queue = []*pb.TaskQueueFetchQueuesResponse_Queue{
+			{
+				QueueName:             []byte("push"),
+				BucketRefillPerSecond: proto.Float64(5),
+				BucketCapacity:        proto.Float64(10),
+				UserSpecifiedRate:     proto.String("5/s"),
+				Paused:                proto.Bool(true),
+				MaxConcurrentRequests: proto.Int32(2),
+				RetryParameters:       &pb.TaskQueueRetryParameters{MinBackoffSec: proto.Float64(0.5)},
+			},
+			{
+				QueueName:             []byte("pull"),
+				BucketRefillPerSecond: proto.Float64(0),
+				BucketCapacity:        proto.Float64(0),
+				Paused:                proto.Bool(false),
+				Mode:                  pb.TaskQueueMode_PULL.Enum(),
+			},
+		}
+		return nil
+	})
+	qs, err := Queues(c, 10)
+	if err != nil {
+		t.Fatalf("Queues: %v", err)
+	}
+	want := []*Queue{
+		{
+			Name:                  "push",
+			Rate:                  5,
+			BucketSize:            10,
+			UserRate:              "5/s",
+			MaxConcurrentRequests: 2,
+			Paused:                true,
+			RetryOptions:          &RetryOptions{MinBackoff: 500 * time.Millisecond},
+		},
+		{
+			Name: "pull",
+			Pull: true,
+		},
+	}
+	if !reflect.DeepEqual(qs, want) {
+		t.Errorf("Queues:\ngot  %+v\nwant %+v", qs, want)
+	}
+}
+
+func TestUpdateQueue(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "taskqueue", "UpdateQueue", func(req *pb.TaskQueueUpdateQueueRequest, res *pb.TaskQueueUpdateQueueResponse) error {
+		want := &pb.TaskQueueUpdateQueueRequest{
+			QueueName:             []byte("q"),
+			BucketRefillPerSecond: proto.Float64(2.5),
+			BucketCapacity:        proto.Int32(4),
+			Mode:                  pb.TaskQueueMode_PULL.Enum(),
+			RetryParameters:       &pb.TaskQueueRetryParameters{RetryLimit: proto.Int32(3)},
+		}
+		if !proto.Equal(req, want) {
+			return fmt.Errorf("got request %v, want %v", req, want)
+		}
+		return nil
+	})
+	q := &Queue{Name: "q", Rate: 2.5, BucketSize: 4, Pull: true, RetryOptions: &RetryOptions{RetryLimit: 3}}
+	if err := UpdateQueue(c, q); err != nil {
+		t.Fatalf("UpdateQueue: %v", err)
+	}
+}
+
+func TestLeaseByOldestTag(t *testing.T) {
+	c := aetesting.FakeSingleContext(t, "taskqueue", "QueryAndOwnTasks", func(req *pb.TaskQueueQueryAndOwnTasksRequest, res *pb.TaskQueueQueryAndOwnTasksResponse) error {
+		if !req.GetGroupByTag() {
+			return fmt.Errorf("GroupByTag is false")
+		}
+		if req.Tag != nil {
+			return fmt.Errorf("Tag = %q, want unset", req.Tag)
+		}
+		res.Task = []*pb.TaskQueueQueryAndOwnTasksResponse_Task{{
+			TaskName:   []byte("t"),
+			EtaUsec:    proto.Int64(1),
+			RetryCount: proto.Int32(0),
+			Tag:        []byte("oldest"),
+		}}
+		return nil
+	})
+	tasks, err := LeaseByTag(c, 10, "pull", 60, "")
+	if err != nil {
+		t.Fatalf("LeaseByTag: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Tag != "oldest" {
+		t.Errorf("LeaseByTag returned %+v, want one task tagged %q", tasks, "oldest")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/appengine/v2/log"
+)
+
+// Defaults of the Consumer fields.
+const (
+	defaultBatchSize   = 100
+	defaultLeaseTime   = time.Minute
+	defaultConcurrency = 10
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = time.Minute
+)
+
+// leaseExtendInterval returns how often the leases of tasks being processed
+// are extended. It is a variable for testing.
+var leaseExtendInterval = func(leaseTime time.Duration) time.Duration {
+	return leaseTime / 2
+}
+
+// A Consumer processes the tasks of a pull queue. It leases tasks in batches
+// and runs Handler for each task, with bounded concurrency. The leases of
+// the tasks are extended while they are processed, and the tasks for which
+// Handler succeeds are deleted when the batch is done. The tasks for which
+// Handler fails are left to be leased again once their lease expires.
+//
+// A task may be processed more than once, for example if its lease could not
+// be extended or it could not be deleted, so Handler should be idempotent.
+//
+// For example, a cron job might run:
+//
+//	cs := &taskqueue.Consumer{QueueName: "pull", Handler: process}
+//	for {
+//		n, err := cs.ProcessBatch(c)
+//		if n == 0 || err != nil {
+//			break
+//		}
+//	}
+type Consumer struct {
+	// QueueName is the name of the pull queue.
+	// An empty queue name means that the default queue will be used.
+	QueueName string
+
+	// Handler processes a task. It must not modify the task.
+	Handler func(c context.Context, t *Task) error
+
+	// BatchSize is the maximum number of tasks leased at once.
+	// If zero, it is 100.
+	BatchSize int
+
+	// LeaseTime is the duration of the leases of the tasks, rounded up
+	// to a second. The leases are extended every LeaseTime/2 while the
+	// tasks are processed. If zero, it is one minute.
+	LeaseTime time.Duration
+
+	// Concurrency is the maximum number of calls to Handler that run at
+	// the same time. If zero, it is 10.
+	Concurrency int
+
+	// If GroupByTag is set, all the tasks of a batch have the same tag:
+	// Tag, or, if Tag is empty, the tag of the task with the earliest ETA.
+	GroupByTag bool
+	Tag        string
+
+	// MinBackoff and MaxBackoff bound how long Run waits before leasing
+	// again when the queue is empty or leasing fails. The wait starts at
+	// MinBackoff and doubles each time, up to MaxBackoff. If zero, they are
+	// one second and one minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func (cs *Consumer) leaseSeconds() int {
+	d := cs.LeaseTime
+	if d <= 0 {
+		d = defaultLeaseTime
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// ProcessBatch leases a batch of tasks and processes them. It returns the
+// number of tasks leased, which is zero if the queue is empty. The error
+// reports failures to lease or delete the tasks; the errors returned by
+// Handler are only logged.
+func (cs *Consumer) ProcessBatch(c context.Context) (int, error) {
+	if cs.Handler == nil {
+		return 0, errors.New("taskqueue: Consumer has no Handler")
+	}
+	batchSize := cs.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	leaseSecs := cs.leaseSeconds()
+	var tasks []*Task
+	var err error
+	if cs.GroupByTag {
+		tasks, err = LeaseByTag(c, batchSize, cs.QueueName, leaseSecs, cs.Tag)
+	} else {
+		tasks, err = Lease(c, batchSize, cs.QueueName, leaseSecs)
+	}
+	if err != nil || len(tasks) == 0 {
+		return 0, err
+	}
+
+	// Handler gets copies of the tasks, as extending a lease modifies the
+	// task's ETA.
+	copies := make([]Task, len(tasks))
+	for i, t := range tasks {
+		copies[i] = *t
+	}
+	var mu sync.Mutex
+	leased := make(map[*Task]bool, len(tasks))
+	for _, t := range tasks {
+		leased[t] = true
+	}
+	done := make(chan struct{})
+	var extender sync.WaitGroup
+	extender.Add(1)
+	go func() {
+		defer extender.Done()
+		cs.extendLeases(c, leaseSecs, &mu, leased, done)
+	}()
+
+	concurrency := cs.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(t, tc *Task) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := cs.Handler(c, tc); err != nil {
+				log.Errorf(c, "taskqueue: task %q failed (will retry): %v", t.Name, err)
+				mu.Lock()
+				delete(leased, t)
+				mu.Unlock()
+			}
+		}(t, &copies[i])
+	}
+	wg.Wait()
+	close(done)
+	extender.Wait()
+
+	// The tasks still leased are those that succeeded.
+	var succeeded []*Task
+	for _, t := range tasks {
+		if leased[t] {
+			succeeded = append(succeeded, t)
+		}
+	}
+	if len(succeeded) > 0 {
+		if err := DeleteMulti(c, succeeded, cs.QueueName); err != nil {
+			return len(tasks), err
+		}
+	}
+	return len(tasks), nil
+}
+
+// extendLeases extends the leases of the tasks in leased until done is
+// closed. A task whose lease cannot be extended is removed from leased.
+func (cs *Consumer) extendLeases(c context.Context, leaseSecs int, mu *sync.Mutex, leased map[*Task]bool, done <-chan struct{}) {
+	ticker := time.NewTicker(leaseExtendInterval(time.Duration(leaseSecs) * time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		mu.Lock()
+		tasks := make([]*Task, 0, len(leased))
+		for t := range leased {
+			tasks = append(tasks, t)
+		}
+		mu.Unlock()
+		for _, t := range tasks {
+			if err := ModifyLease(c, t, cs.QueueName, leaseSecs); err != nil {
+				log.Warningf(c, "taskqueue: extending the lease of task %q: %v", t.Name, err)
+				mu.Lock()
+				delete(leased, t)
+				mu.Unlock()
+			}
+		}
+	}
+}
+
+// Run processes batches of tasks until c is done, when it returns c.Err().
+// When the queue is empty or leasing fails, it waits before leasing again,
+// as configured by MinBackoff and MaxBackoff.
+//
+// As App Engine requests have a deadline, Run is meant for manual scaling
+// instances and background contexts; request handlers should call
+// ProcessBatch instead.
+func (cs *Consumer) Run(c context.Context) error {
+	minBackoff, maxBackoff := cs.MinBackoff, cs.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	var backoff time.Duration
+	for {
+		if err := c.Err(); err != nil {
+			return err
+		}
+		n, err := cs.ProcessBatch(c)
+		if err != nil {
+			log.Errorf(c, "taskqueue: processing queue %q: %v", cs.QueueName, err)
+		}
+		if n > 0 && err == nil {
+			backoff = 0
+			continue
+		}
+		if backoff == 0 {
+			backoff = minBackoff
+		} else if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-c.Done():
+			t.Stop()
+			return c.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+// fakePullQueue is an in-memory pull queue. Leased tasks are not leased
+// again, as the tests do not wait for leases to expire.
+type fakePullQueue struct {
+	mu       sync.Mutex
+	tasks    []string
+	deleted  []string
+	leases   int
+	extended map[string]int
+}
+
+func (q *fakePullQueue) context() context.Context {
+	q.extended = make(map[string]int)
+	c := internal.WithCallOverride(context.Background(), q.call)
+	return internal.WithLogOverride(c, func(level int64, format string, args ...interface{}) {})
+}
+
+func (q *fakePullQueue) call(ctx context.Context, service, method string, in, out proto.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch service + "." + method {
+	case "taskqueue.QueryAndOwnTasks":
+		req, res := in.(*pb.TaskQueueQueryAndOwnTasksRequest), out.(*pb.TaskQueueQueryAndOwnTasksResponse)
+		q.leases++
+		n := int(req.GetMaxTasks())
+		if n > len(q.tasks) {
+			n = len(q.tasks)
+		}
+		for _, name := range q.tasks[:n] {
+			res.Task = append(res.Task, &pb.TaskQueueQueryAndOwnTasksResponse_Task{
+				TaskName:   []byte(name),
+				EtaUsec:    proto.Int64(1),
+				RetryCount: proto.Int32(0),
+				Body:       []byte(name),
+			})
+		}
+		q.tasks = q.tasks[n:]
+	case "taskqueue.ModifyTaskLease":
+		req, res := in.(*pb.TaskQueueModifyTaskLeaseRequest), out.(*pb.TaskQueueModifyTaskLeaseResponse)
+		q.extended[string(req.TaskName)]++
+		res.UpdatedEtaUsec = proto.Int64(req.GetEtaUsec() + 1)
+	case "taskqueue.Delete":
+		req, res := in.(*pb.TaskQueueDeleteRequest), out.(*pb.TaskQueueDeleteResponse)
+		for _, name := range req.TaskName {
+			q.deleted = append(q.deleted, string(name))
+			res.Result = append(res.Result, pb.TaskQueueServiceError_OK)
+		}
+	default:
+		return fmt.Errorf("unexpected call %s.%s", service, method)
+	}
+	return nil
+}
+
+func TestConsumerProcessBatch(t *testing.T) {
+	q := &fakePullQueue{}
+	for i := 0; i < 12; i++ {
+		q.tasks = append(q.tasks, fmt.Sprintf("t%02d", i))
+	}
+	c := q.context()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	cs := &Consumer{
+		QueueName:   "pull",
+		BatchSize:   10,
+		Concurrency: 3,
+		Handler: func(c context.Context, task *Task) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			if string(task.Payload) == "t03" {
+				return errors.New("failed")
+			}
+			return nil
+		},
+	}
+	n, err := cs.ProcessBatch(c)
+	if err != nil || n != 10 {
+		t.Fatalf("ProcessBatch = %d, %v; want 10, nil", n, err)
+	}
+	if maxRunning != 3 {
+		t.Errorf("ran up to %d handlers at once, want 3", maxRunning)
+	}
+	sort.Strings(q.deleted)
+	want := []string{"t00", "t01", "t02", "t04", "t05", "t06", "t07", "t08", "t09"}
+	if fmt.Sprint(q.deleted) != fmt.Sprint(want) {
+		t.Errorf("deleted %v, want %v", q.deleted, want)
+	}
+
+	n, err = cs.ProcessBatch(c)
+	if err != nil || n != 2 {
+		t.Errorf("second ProcessBatch = %d, %v; want 2, nil", n, err)
+	}
+	n, err = cs.ProcessBatch(c)
+	if err != nil || n != 0 {
+		t.Errorf("ProcessBatch of an empty queue = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestConsumerExtendsLeases(t *testing.T) {
+	defer func(f func(time.Duration) time.Duration) { leaseExtendInterval = f }(leaseExtendInterval)
+	leaseExtendInterval = func(time.Duration) time.Duration { return 5 * time.Millisecond }
+
+	q := &fakePullQueue{tasks: []string{"fast", "slow"}}
+	cs := &Consumer{
+		LeaseTime: 1500 * time.Millisecond,
+		Handler: func(c context.Context, task *Task) error {
+			if task.Name == "slow" {
+				time.Sleep(50 * time.Millisecond)
+			}
+			return nil
+		},
+	}
+	if got := cs.leaseSeconds(); got != 2 {
+		t.Errorf("leaseSeconds = %d, want 2", got)
+	}
+	if _, err := cs.ProcessBatch(q.context()); err != nil {
+		t.Fatalf("ProcessBatch: %v", err)
+	}
+	// Both leases are extended until the batch is done, as the succeeded
+	// tasks are only deleted then.
+	if q.extended["slow"] < 2 || q.extended["fast"] < 2 {
+		t.Errorf("leases extended %v times, want at least 2 each", q.extended)
+	}
+	if len(q.deleted) != 2 {
+		t.Errorf("deleted %v, want both tasks", q.deleted)
+	}
+}
+
+func TestConsumerRun(t *testing.T) {
+	q := &fakePullQueue{tasks: []string{"a", "b", "c"}}
+	c, cancel := context.WithCancel(q.context())
+	defer cancel()
+	cs := &Consumer{
+		BatchSize:  2,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 4 * time.Millisecond,
+		Handler: func(c context.Context, task *Task) error {
+			return nil
+		},
+	}
+	go func() {
+		for {
+			q.mu.Lock()
+			leases := q.leases
+			q.mu.Unlock()
+			if leases >= 6 {
+				cancel()
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	if err := cs.Run(c); err != context.Canceled {
+		t.Errorf("Run = %v, want %v", err, context.Canceled)
+	}
+	if len(q.deleted) != 3 {
+		t.Errorf("deleted %v, want all three tasks", q.deleted)
+	}
+}
+
+func TestConsumerWithoutHandler(t *testing.T) {
+	if _, err := (&Consumer{}).ProcessBatch(context.Background()); err == nil {
+		t.Error("ProcessBatch without a Handler succeeded")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/log"
+)
+
+// Content types of the payloads of tasks created by NewJSONTask and
+// NewProtoTask.
+const (
+	JSONContentType  = "application/json"
+	ProtoContentType = "application/x-protobuf"
+)
+
+func newPayloadTask(path, contentType string, payload []byte) *Task {
+	h := make(http.Header)
+	h.Set("Content-Type", contentType)
+	return &Task{
+		Path:    path,
+		Payload: payload,
+		Header:  h,
+		Method:  "POST",
+	}
+}
+
+// NewJSONTask creates a Task that will POST v, encoded as JSON, to a path.
+func NewJSONTask(path string, v interface{}) (*Task, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("taskqueue: encoding JSON payload: %v", err)
+	}
+	return newPayloadTask(path, JSONContentType, b), nil
+}
+
+// NewProtoTask creates a Task that will POST m, encoded in the protocol
+// buffer wire format, to a path.
+func NewProtoTask(path string, m proto.Message) (*Task, error) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("taskqueue: encoding proto payload: %v", err)
+	}
+	return newPayloadTask(path, ProtoContentType, b), nil
+}
+
+// DecodePayload decodes the body of a task request into v, according to its
+// Content-Type header: as a protocol buffer if it is ProtoContentType, in
+// which case v must be a proto.Message, and as JSON otherwise.
+func DecodePayload(req *http.Request, v interface{}) error {
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return decodePayload(req.Header.Get("Content-Type"), b, v)
+}
+
+func decodePayload(contentType string, b []byte, v interface{}) error {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case ProtoContentType:
+		m, ok := v.(proto.Message)
+		if !ok {
+			return NoRetry(fmt.Errorf("taskqueue: cannot decode a proto payload into %T", v))
+		}
+		if err := proto.Unmarshal(b, m); err != nil {
+			return NoRetry(fmt.Errorf("taskqueue: decoding proto payload: %v", err))
+		}
+	case JSONContentType, "":
+		if err := json.Unmarshal(b, v); err != nil {
+			return NoRetry(fmt.Errorf("taskqueue: decoding JSON payload: %v", err))
+		}
+	default:
+		return NoRetry(fmt.Errorf("taskqueue: unsupported payload content type %q", contentType))
+	}
+	return nil
+}
+
+// noRetryError is an error that retrying a task cannot fix.
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string { return e.err.Error() }
+
+func (e *noRetryError) Unwrap() error { return e.err }
+
+// NoRetry wraps err to report that a task failed in a way that retrying
+// cannot fix, such as a malformed payload. A handler created by Handler
+// that returns such an error acknowledges the task, so that it is not
+// retried. NoRetry(nil) returns nil.
+func NoRetry(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &noRetryError{err}
+}
+
+// IsNoRetry reports whether err, or an error it wraps, was returned by
+// NoRetry.
+func IsNoRetry(err error) bool {
+	var e *noRetryError
+	return errors.As(err, &e)
+}
+
+type contextKey int
+
+const headersContextKey contextKey = 0
+
+// RequestHeadersFromContext returns the headers of the task request being
+// handled by a handler created by Handler, JSONHandler or ProtoHandler.
+func RequestHeadersFromContext(c context.Context) (*RequestHeaders, bool) {
+	h, ok := c.Value(headersContextKey).(*RequestHeaders)
+	return h, ok
+}
+
+// Handler returns an http.Handler for push tasks that calls fn with the
+// request's context, to which the parsed task request headers have been
+// added, and replies with a status that tells the task queue whether to
+// retry the task:
+//   - if fn returns nil, with 200 OK, so that the task is deleted;
+//   - if fn returns an error created by NoRetry, with 200 OK after logging
+//     the error, so that the task is deleted;
+//   - otherwise with 500 Internal Server Error, so that the task is retried.
+//
+// The headers may be retrieved with RequestHeadersFromContext.
+func Handler(fn func(c context.Context, req *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer req.Body.Close()
+		c := context.WithValue(appengine.NewContext(req), headersContextKey, ParseRequestHeaders(req.Header))
+		err := fn(c, req)
+		switch {
+		case err == nil:
+		case IsNoRetry(err):
+			log.Errorf(c, "taskqueue: task failed (will not retry): %v", err)
+		default:
+			log.Errorf(c, "taskqueue: task failed (will retry): %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	})
+}
+
+// RequireTaskQueue wraps a handler to reject requests that were not made by
+// the Task Queue service, with status 403 Forbidden. App Engine removes the
+// X-AppEngine-QueueName header from external requests, so its presence shows
+// that a request comes from a queue.
+func RequireTaskQueue(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-AppEngine-QueueName") == "" {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
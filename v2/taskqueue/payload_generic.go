@@ -0,0 +1,48 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package taskqueue
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// JSONHandler returns a Handler that decodes the JSON payload of each task
+// into a new value of type T and passes it to fn. Tasks whose payload cannot
+// be decoded are not retried. For example:
+//
+//	http.Handle("/tasks/resize", taskqueue.JSONHandler(func(c context.Context, r ResizeRequest) error {
+//		...
+//	}))
+func JSONHandler[T any](fn func(c context.Context, v T) error) http.Handler {
+	return Handler(func(c context.Context, req *http.Request) error {
+		var v T
+		if err := DecodePayload(req, &v); err != nil {
+			return err
+		}
+		return fn(c, v)
+	})
+}
+
+// ProtoHandler returns a Handler that decodes the protocol buffer payload of
+// each task into a new message of type *T and passes it to fn. Tasks whose
+// payload cannot be decoded are not retried.
+func ProtoHandler[T any, PT interface {
+	*T
+	proto.Message
+}](fn func(c context.Context, m PT) error) http.Handler {
+	return Handler(func(c context.Context, req *http.Request) error {
+		m := PT(new(T))
+		if err := DecodePayload(req, m); err != nil {
+			return err
+		}
+		return fn(c, m)
+	})
+}
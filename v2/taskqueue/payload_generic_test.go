@@ -0,0 +1,63 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package taskqueue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+func TestJSONHandler(t *testing.T) {
+	var got resize
+	h := JSONHandler(func(c context.Context, r resize) error {
+		got = r
+		return nil
+	})
+	task, err := NewJSONTask("/resize", resize{"b.png", 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, taskRequest(task, "q"))
+	if rw.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rw.Code, http.StatusOK)
+	}
+	if want := (resize{"b.png", 50}); got != want {
+		t.Errorf("handler got %+v, want %+v", got, want)
+	}
+
+	// A malformed payload is not retried.
+	task.Payload = []byte("[")
+	got = resize{}
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, taskRequest(task, "q"))
+	if rw.Code != http.StatusOK || got != (resize{}) {
+		t.Errorf("malformed payload: status %d, handler got %+v", rw.Code, got)
+	}
+}
+
+func TestProtoHandler(t *testing.T) {
+	var got string
+	h := ProtoHandler(func(c context.Context, m *pb.TaskQueueAddResponse) error {
+		got = string(m.ChosenTaskName)
+		return nil
+	})
+	task, err := NewProtoTask("/p", &pb.TaskQueueAddResponse{ChosenTaskName: []byte("name")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, taskRequest(task, "q"))
+	if rw.Code != http.StatusOK || got != "name" {
+		t.Errorf("status %d, handler got %q; want %d, %q", rw.Code, got, http.StatusOK, "name")
+	}
+}
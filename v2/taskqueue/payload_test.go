@@ -0,0 +1,132 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+type resize struct {
+	Image string
+	Width int
+}
+
+// taskRequest returns a request delivering task from the given queue, with a
+// context that discards logs.
+func taskRequest(task *Task, queueName string) *http.Request {
+	req := httptest.NewRequest(task.method(), task.Path, bytes.NewReader(task.Payload))
+	for k, vs := range task.Header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("X-AppEngine-QueueName", queueName)
+	c := internal.WithLogOverride(context.Background(), func(level int64, format string, args ...interface{}) {})
+	return req.WithContext(c)
+}
+
+func TestNewJSONTask(t *testing.T) {
+	task, err := NewJSONTask("/resize", resize{"a.png", 100})
+	if err != nil {
+		t.Fatalf("NewJSONTask: %v", err)
+	}
+	if got := task.Header.Get("Content-Type"); got != JSONContentType {
+		t.Errorf("Content-Type = %q, want %q", got, JSONContentType)
+	}
+	var r resize
+	if err := DecodePayload(taskRequest(task, "q"), &r); err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if want := (resize{"a.png", 100}); r != want {
+		t.Errorf("DecodePayload = %+v, want %+v", r, want)
+	}
+
+	if _, err := NewJSONTask("/resize", func() {}); err == nil {
+		t.Error("NewJSONTask of a func succeeded")
+	}
+}
+
+func TestNewProtoTask(t *testing.T) {
+	m := &pb.TaskQueueAddResponse{ChosenTaskName: []byte("t")}
+	task, err := NewProtoTask("/p", m)
+	if err != nil {
+		t.Fatalf("NewProtoTask: %v", err)
+	}
+	if got := task.Header.Get("Content-Type"); got != ProtoContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ProtoContentType)
+	}
+	got := &pb.TaskQueueAddResponse{}
+	if err := DecodePayload(taskRequest(task, "q"), got); err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if !proto.Equal(got, m) {
+		t.Errorf("DecodePayload = %v, want %v", got, m)
+	}
+
+	var r resize
+	if err := DecodePayload(taskRequest(task, "q"), &r); !IsNoRetry(err) {
+		t.Errorf("DecodePayload of a proto into a struct: got %v, want a NoRetry error", err)
+	}
+}
+
+func TestDecodePayloadErrors(t *testing.T) {
+	tests := []struct {
+		contentType string
+		payload     string
+	}{
+		{JSONContentType, "{"},
+		{ProtoContentType, "\xff"},
+		{"text/plain", "hello"},
+	}
+	for _, tc := range tests {
+		var v interface{} = &pb.TaskQueueAddResponse{}
+		err := decodePayload(tc.contentType, []byte(tc.payload), v)
+		if !IsNoRetry(err) {
+			t.Errorf("decodePayload(%q, %q): got %v, want a NoRetry error", tc.contentType, tc.payload, err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	errFail := errors.New("fail")
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{errFail, http.StatusInternalServerError},
+		{NoRetry(errFail), http.StatusOK},
+		{fmt.Errorf("wrapped: %w", NoRetry(errFail)), http.StatusOK},
+	}
+	for _, tc := range tests {
+		var headers *RequestHeaders
+		h := Handler(func(c context.Context, req *http.Request) error {
+			headers, _ = RequestHeadersFromContext(c)
+			return tc.err
+		})
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, taskRequest(&Task{Path: "/work"}, "q"))
+		if rw.Code != tc.want {
+			t.Errorf("handler returning %v: got status %d, want %d", tc.err, rw.Code, tc.want)
+		}
+		if want := (&RequestHeaders{QueueName: "q"}); !reflect.DeepEqual(headers, want) {
+			t.Errorf("RequestHeadersFromContext = %+v, want %+v", headers, want)
+		}
+	}
+
+	if _, ok := RequestHeadersFromContext(context.Background()); ok {
+		t.Error("RequestHeadersFromContext outside a handler succeeded")
+	}
+}
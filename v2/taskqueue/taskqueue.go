@@ -13,6 +13,15 @@ taskqueue operation is to add a single POST task, NewPOSTTask makes it easy.
 		"key": {key},
 	})
 	taskqueue.Add(c, t, "") // add t to the default queue
+
+NewJSONTask and NewProtoTask create tasks carrying a typed payload, which
+handlers created by JSONHandler and ProtoHandler decode:
+
+	t, err := taskqueue.NewJSONTask("/resize", ResizeRequest{Image: name, Width: 200})
+
+	http.Handle("/resize", taskqueue.JSONHandler(func(c context.Context, r ResizeRequest) error {
+		...
+	}))
 */
 package taskqueue // import "google.golang.org/appengine/v2/taskqueue"
 
@@ -282,13 +291,20 @@ var alreadyAddedErrors = map[pb.TaskQueueServiceError_ErrorCode]bool{
 // An empty queue name means that the default queue will be used.
 // Add returns an equivalent Task with defaults filled in, including setting
 // the task's Name field to the chosen name if the original was empty.
+// If c is a transaction context, the task is added when the transaction
+// commits, and ErrTooManyTransactionalTasks is returned if the transaction
+// has already added MaxTransactionalTasks tasks.
 func Add(c context.Context, task *Task, queueName string) (*Task, error) {
 	req, err := newAddReq(c, task, queueName)
 	if err != nil {
 		return nil, err
 	}
+	if err := reserveTransactionalTasks(c, 1); err != nil {
+		return nil, err
+	}
 	res := &pb.TaskQueueAddResponse{}
 	if err := internal.Call(c, "taskqueue", "Add", req, res); err != nil {
+		internal.AddTransactionalTasks(c, -1)
 		apiErr, ok := err.(*internal.APIError)
 		if ok && alreadyAddedErrors[pb.TaskQueueServiceError_ErrorCode(apiErr.Code)] {
 			return nil, ErrTaskAlreadyAdded
@@ -308,6 +324,10 @@ func Add(c context.Context, task *Task, queueName string) (*Task, error) {
 // AddMulti returns a slice of equivalent tasks with defaults filled in, including setting
 // each task's Name field to the chosen name if the original was empty.
 // If a given task is badly formed or could not be added, an appengine.MultiError is returned.
+// If c is a transaction context, the tasks are added when the transaction
+// commits, and ErrTooManyTransactionalTasks is returned if they would take
+// the transaction over MaxTransactionalTasks tasks; AddMultiSpill may be
+// used instead.
 func AddMulti(c context.Context, tasks []*Task, queueName string) ([]*Task, error) {
 	req := &pb.TaskQueueBulkAddRequest{
 		AddRequest: make([]*pb.TaskQueueAddRequest, len(tasks)),
@@ -320,8 +340,12 @@ func AddMulti(c context.Context, tasks []*Task, queueName string) ([]*Task, erro
 	if any {
 		return nil, me
 	}
+	if err := reserveTransactionalTasks(c, len(tasks)); err != nil {
+		return nil, err
+	}
 	res := &pb.TaskQueueBulkAddResponse{}
 	if err := internal.Call(c, "taskqueue", "BulkAdd", req, res); err != nil {
+		internal.AddTransactionalTasks(c, -len(tasks))
 		return nil, err
 	}
 	if len(res.Taskresult) != len(tasks) {
@@ -336,6 +360,7 @@ func AddMulti(c context.Context, tasks []*Task, queueName string) ([]*Task, erro
 			tasksOut[i].Name = string(tr.ChosenTaskName)
 		}
 		if *tr.Result != pb.TaskQueueServiceError_OK {
+			internal.AddTransactionalTasks(c, -1)
 			if alreadyAddedErrors[*tr.Result] {
 				me[i] = ErrTaskAlreadyAdded
 			} else {
@@ -410,7 +435,11 @@ func lease(c context.Context, maxTasks int, queueName string, leaseTime int, gro
 		LeaseSeconds: proto.Float64(float64(leaseTime)),
 		MaxTasks:     proto.Int64(int64(maxTasks)),
 		GroupByTag:   proto.Bool(groupByTag),
-		Tag:          tag,
+	}
+	if len(tag) > 0 {
+		// An empty tag must be left unset, so that tasks are grouped by
+		// the tag of the task with the earliest ETA.
+		req.Tag = tag
 	}
 	res := &pb.TaskQueueQueryAndOwnTasksResponse{}
 	if err := internal.Call(c, "taskqueue", "QueryAndOwnTasks", req, res); err != nil {
@@ -0,0 +1,141 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2"
+	"google.golang.org/appengine/v2/internal"
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+// MaxTransactionalTasks is the maximum number of tasks that may be added in
+// a datastore transaction.
+const MaxTransactionalTasks = 5
+
+// ErrTooManyTransactionalTasks is the error returned by Add and AddMulti when
+// adding tasks in a transaction would take it over MaxTransactionalTasks
+// tasks.
+var ErrTooManyTransactionalTasks = fmt.Errorf("taskqueue: more than %d tasks added in transaction", MaxTransactionalTasks)
+
+// reserveTransactionalTasks counts n tasks about to be added in the
+// transaction of c, if any, or returns ErrTooManyTransactionalTasks if the
+// transaction has no room for them. The caller must release the tasks that
+// are not added with internal.AddTransactionalTasks(c, -n).
+func reserveTransactionalTasks(c context.Context, n int) error {
+	if !internal.InTransaction(c) {
+		return nil
+	}
+	if internal.AddTransactionalTasks(c, n) > MaxTransactionalTasks {
+		internal.AddTransactionalTasks(c, -n)
+		return ErrTooManyTransactionalTasks
+	}
+	return nil
+}
+
+// spillPath is the path of the spill tasks added by AddMultiSpill.
+var spillPath = "/_ah/queue/go/taskqueue-spill"
+
+// SetSpillPath sets the URL path of the spill tasks added by AddMultiSpill,
+// for applications that serve SpillHandler at a path other than
+// "/_ah/queue/go/taskqueue-spill". The new path applies only to spill tasks
+// added after the call, so SetSpillPath should be called during
+// initialization.
+func SetSpillPath(p string) {
+	spillPath = p
+}
+
+// maxBulkAdd is the largest number of tasks added by one BulkAdd call.
+const maxBulkAdd = 100
+
+// AddMultiSpill adds multiple tasks to a named queue, like AddMulti, but is
+// not limited by MaxTransactionalTasks. If c is a transaction context and the
+// transaction has no room left for the tasks, AddMultiSpill adds a single
+// transactional "spill" task to the default queue instead, which carries the
+// tasks and adds them, non-transactionally, when it runs. Spilled tasks
+// without a name are named after the spill task, so that they are added only
+// once if the spill task is retried.
+//
+// The application must serve SpillHandler at the path of the spill tasks,
+// "/_ah/queue/go/taskqueue-spill" unless changed with SetSpillPath. Since a
+// task's payload is limited in size, AddMultiSpill is only suitable for tasks
+// with small payloads.
+func AddMultiSpill(c context.Context, tasks []*Task, queueName string) error {
+	if !internal.InTransaction(c) || len(tasks) <= MaxTransactionalTasks-internal.AddTransactionalTasks(c, 0) {
+		_, err := AddMulti(c, tasks, queueName)
+		return err
+	}
+	req := &pb.TaskQueueBulkAddRequest{
+		AddRequest: make([]*pb.TaskQueueAddRequest, len(tasks)),
+	}
+	me, any := make(appengine.MultiError, len(tasks)), false
+	for i, t := range tasks {
+		req.AddRequest[i], me[i] = newAddReq(c, t, queueName)
+		any = any || me[i] != nil
+	}
+	if any {
+		return me
+	}
+	payload, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	spill := newPayloadTask(spillPath, ProtoContentType, payload)
+	_, err = Add(c, spill, "")
+	return err
+}
+
+// addSpilled adds the tasks carried by a spill task.
+func addSpilled(c context.Context, req *http.Request) error {
+	var bulk pb.TaskQueueBulkAddRequest
+	if err := DecodePayload(req, &bulk); err != nil {
+		return err
+	}
+	h, _ := RequestHeadersFromContext(c)
+	reqs := bulk.AddRequest
+	for i, r := range reqs {
+		if len(r.TaskName) == 0 {
+			r.TaskName = []byte(fmt.Sprintf("spill-%s-%d", h.TaskName, i))
+		}
+	}
+	for len(reqs) > 0 {
+		n := len(reqs)
+		if n > maxBulkAdd {
+			n = maxBulkAdd
+		}
+		res := &pb.TaskQueueBulkAddResponse{}
+		if err := internal.Call(c, "taskqueue", "BulkAdd", &pb.TaskQueueBulkAddRequest{AddRequest: reqs[:n]}, res); err != nil {
+			return err
+		}
+		if len(res.Taskresult) != n {
+			return errors.New("taskqueue: server error")
+		}
+		for _, tr := range res.Taskresult {
+			if ec := tr.GetResult(); ec != pb.TaskQueueServiceError_OK && !alreadyAddedErrors[ec] {
+				return &internal.APIError{
+					Service: "taskqueue",
+					Code:    int32(ec),
+				}
+			}
+		}
+		reqs = reqs[n:]
+	}
+	return nil
+}
+
+// SpillHandler returns a handler that runs the spill tasks added by
+// AddMultiSpill. As it adds arbitrary tasks, it rejects requests that were
+// not made by the Task Queue service, like RequireTaskQueue. For example:
+//
+//	http.Handle("/_ah/queue/go/taskqueue-spill", taskqueue.SpillHandler())
+func SpillHandler() http.Handler {
+	return RequireTaskQueue(Handler(addSpilled))
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package taskqueue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/appengine/v2/datastore"
+	"google.golang.org/appengine/v2/internal"
+	dspb "google.golang.org/appengine/v2/internal/datastore"
+	pb "google.golang.org/appengine/v2/internal/taskqueue"
+)
+
+// fakeQueue records the tasks added through it, rejecting tasks named in
+// fail and tasks whose name was already added.
+type fakeQueue struct {
+	added []*pb.TaskQueueAddRequest
+	names map[string]bool
+	fail  map[string]bool
+	calls int
+}
+
+func (q *fakeQueue) context() context.Context {
+	q.names = make(map[string]bool)
+	c := internal.WithCallOverride(context.Background(), q.call)
+	c = internal.WithAppIDOverride(c, "dev~fake-app")
+	return internal.WithLogOverride(c, func(level int64, format string, args ...interface{}) {})
+}
+
+func (q *fakeQueue) add(req *pb.TaskQueueAddRequest) pb.TaskQueueServiceError_ErrorCode {
+	name := string(req.TaskName)
+	switch {
+	case q.fail[name]:
+		return pb.TaskQueueServiceError_INTERNAL_ERROR
+	case q.names[name]:
+		return pb.TaskQueueServiceError_TASK_ALREADY_EXISTS
+	}
+	if name != "" {
+		q.names[name] = true
+	}
+	q.added = append(q.added, req)
+	return pb.TaskQueueServiceError_OK
+}
+
+func (q *fakeQueue) call(ctx context.Context, service, method string, in, out proto.Message) error {
+	switch service + "." + method {
+	case "datastore_v3.BeginTransaction":
+		out.(*dspb.Transaction).Handle = proto.Uint64(1)
+		out.(*dspb.Transaction).App = proto.String("dev~fake-app")
+	case "datastore_v3.Commit", "datastore_v3.Rollback":
+	case "taskqueue.Add":
+		q.calls++
+		if ec := q.add(in.(*pb.TaskQueueAddRequest)); ec != pb.TaskQueueServiceError_OK {
+			return &internal.APIError{Service: "taskqueue", Code: int32(ec)}
+		}
+	case "taskqueue.BulkAdd":
+		q.calls++
+		res := out.(*pb.TaskQueueBulkAddResponse)
+		for _, req := range in.(*pb.TaskQueueBulkAddRequest).AddRequest {
+			res.Taskresult = append(res.Taskresult, &pb.TaskQueueBulkAddResponse_TaskResult{Result: q.add(req).Enum()})
+		}
+	default:
+		return fmt.Errorf("unexpected call %s.%s", service, method)
+	}
+	return nil
+}
+
+func newTasks(n int) []*Task {
+	tasks := make([]*Task, n)
+	for i := range tasks {
+		tasks[i] = &Task{Path: fmt.Sprintf("/work/%d", i)}
+	}
+	return tasks
+}
+
+func TestTransactionalTaskLimit(t *testing.T) {
+	q := &fakeQueue{}
+	err := datastore.RunInTransaction(q.context(), func(tc context.Context) error {
+		if _, err := AddMulti(tc, newTasks(3), ""); err != nil {
+			return err
+		}
+		if _, err := AddMulti(tc, newTasks(3), ""); err != ErrTooManyTransactionalTasks {
+			t.Errorf("AddMulti over the limit: got %v, want %v", err, ErrTooManyTransactionalTasks)
+		}
+		// A failed task does not count.
+		q.fail = map[string]bool{"bad": true}
+		if _, err := Add(tc, &Task{Name: "bad"}, ""); err == nil {
+			t.Errorf("Add of a failing task succeeded")
+		}
+		if _, err := AddMulti(tc, newTasks(2), ""); err != nil {
+			return err
+		}
+		if _, err := Add(tc, &Task{}, ""); err != ErrTooManyTransactionalTasks {
+			t.Errorf("Add over the limit: got %v, want %v", err, ErrTooManyTransactionalTasks)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if len(q.added) != 5 || q.calls != 3 {
+		t.Errorf("added %d tasks in %d calls, want 5 in 3", len(q.added), q.calls)
+	}
+
+	// Outside a transaction, there is no limit.
+	if _, err := AddMulti(q.context(), newTasks(10), ""); err != nil {
+		t.Errorf("AddMulti outside a transaction: %v", err)
+	}
+}
+
+func TestAddMultiSpill(t *testing.T) {
+	q := &fakeQueue{}
+	c := q.context()
+	err := datastore.RunInTransaction(c, func(tc context.Context) error {
+		if err := AddMultiSpill(tc, newTasks(2), "q"); err != nil {
+			return err
+		}
+		tasks := newTasks(4)
+		tasks[3].Name = "named"
+		return AddMultiSpill(tc, tasks, "q")
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if len(q.added) != 3 {
+		t.Fatalf("added %d tasks in the transaction, want 3", len(q.added))
+	}
+	spill := q.added[2]
+	if got := string(spill.Url); got != spillPath {
+		t.Fatalf("spill task path = %q, want %q", got, spillPath)
+	}
+
+	// Run the spill task twice, as if it were retried.
+	q.added = nil
+	task := &Task{Path: spillPath, Payload: spill.Body, Header: http.Header{"Content-Type": {ProtoContentType}}}
+	for i := 0; i < 2; i++ {
+		req := taskRequest(task, "default")
+		req.Header.Set("X-AppEngine-TaskName", "42")
+		rw := httptest.NewRecorder()
+		SpillHandler().ServeHTTP(rw, req.WithContext(c))
+		if rw.Code != http.StatusOK {
+			t.Fatalf("spill handler returned status %d", rw.Code)
+		}
+	}
+	var names, paths []string
+	for _, r := range q.added {
+		names = append(names, string(r.TaskName))
+		paths = append(paths, string(r.QueueName)+string(r.Url))
+	}
+	if want := []string{"spill-42-0", "spill-42-1", "spill-42-2", "named"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("spilled task names = %q, want %q", names, want)
+	}
+	if want := []string{"q/work/0", "q/work/1", "q/work/2", "q/work/3"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("spilled tasks = %q, want %q", paths, want)
+	}
+}
+
+func TestSpillHandlerRejectsExternalRequests(t *testing.T) {
+	rw := httptest.NewRecorder()
+	SpillHandler().ServeHTTP(rw, httptest.NewRequest("POST", spillPath, nil))
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rw.Code, http.StatusForbidden)
+	}
+}
+
+func TestSetSpillPath(t *testing.T) {
+	defer SetSpillPath(spillPath)
+	SetSpillPath("/tasks/spill")
+	q := &fakeQueue{}
+	err := datastore.RunInTransaction(q.context(), func(tc context.Context) error {
+		return AddMultiSpill(tc, newTasks(MaxTransactionalTasks+1), "")
+	}, nil)
+	if err != nil {
+		t.Fatalf("RunInTransaction: %v", err)
+	}
+	if len(q.added) != 1 || string(q.added[0].Url) != "/tasks/spill" {
+		t.Errorf("added %d tasks, want one spill task with path %q", len(q.added), "/tasks/spill")
+	}
+}